@@ -1,5 +1,7 @@
 package notionapi
 
+import "context"
+
 type navigableBlockID struct {
 	ID string `json:"id"`
 }
@@ -25,6 +27,11 @@ type GetActivityLogResponse struct {
 // If startingAfterId is "", starts at the most recent log entry.
 // navBlockID is the ID of a navigable block (like a page in a database)
 func (c *Client) GetActivityLog(spaceID string, startingAfterID string, navBlockID string, limit int) (*GetActivityLogResponse, error) {
+	return c.GetActivityLogCtx(context.Background(), spaceID, startingAfterID, navBlockID, limit)
+}
+
+// GetActivityLogCtx is like GetActivityLog but can be cancelled with ctx
+func (c *Client) GetActivityLogCtx(ctx context.Context, spaceID string, startingAfterID string, navBlockID string, limit int) (*GetActivityLogResponse, error) {
 	req := &getActivityLogRequest{
 		SpaceID:         spaceID,
 		StartingAfterID: startingAfterID,
@@ -34,7 +41,7 @@ func (c *Client) GetActivityLog(spaceID string, startingAfterID string, navBlock
 	var rsp GetActivityLogResponse
 	var err error
 	apiURL := "/api/v3/getActivityLog"
-	if err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON); err != nil {
+	if err = c.doNotionAPI(ctx, apiURL, req, &rsp, &rsp.RawJSON); err != nil {
 		return nil, err
 	}
 	if err = ParseRecordMap(rsp.RecordMap); err != nil {
@@ -1,5 +1,7 @@
 package notionapi
 
+import "context"
+
 type permissionRecord struct {
 	ID      string `json:"id"`
 	Table   string `json:"table"`
@@ -25,6 +27,11 @@ type GetSignedURLsResponse struct {
 
 // GetSignedURLs executes a raw API call /api/v3/getSignedFileUrls
 func (c *Client) GetSignedURLs(urls []string, block *Block) (*GetSignedURLsResponse, error) {
+	return c.GetSignedURLsCtx(context.Background(), urls, block)
+}
+
+// GetSignedURLsCtx is like GetSignedURLs but can be cancelled with ctx
+func (c *Client) GetSignedURLsCtx(ctx context.Context, urls []string, block *Block) (*GetSignedURLsResponse, error) {
 	permRec := &permissionRecord{
 		ID:      block.ID,
 		Table:   block.ParentTable,
@@ -45,7 +52,7 @@ func (c *Client) GetSignedURLs(urls []string, block *Block) (*GetSignedURLsRespo
 	var rsp GetSignedURLsResponse
 	var err error
 	apiURL := "/api/v3/getSignedFileUrls"
-	if err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON); err != nil {
+	if err = c.doNotionAPI(ctx, apiURL, req, &rsp, &rsp.RawJSON); err != nil {
 		return nil, err
 	}
 	return &rsp, nil
@@ -1,6 +1,7 @@
 package notionapi
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"mime"
@@ -35,7 +36,7 @@ func (r *GetUploadFileUrlResponse) Parse() {
 }
 
 // getUploadFileURL executes a raw API call: POST /api/v3/getUploadFileUrl
-func (c *Client) getUploadFileURL(name, contentType string) (*GetUploadFileUrlResponse, error) {
+func (c *Client) getUploadFileURL(ctx context.Context, name, contentType string) (*GetUploadFileUrlResponse, error) {
 
 	req := &getUploadFileUrlRequest{
 		Bucket:      "secure",
@@ -46,7 +47,7 @@ func (c *Client) getUploadFileURL(name, contentType string) (*GetUploadFileUrlRe
 	var rsp GetUploadFileUrlResponse
 	var err error
 	const apiURL = "/api/v3/getUploadFileUrl"
-	err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON)
+	err = c.doNotionAPI(ctx, apiURL, req, &rsp, &rsp.RawJSON)
 	if err != nil {
 		return nil, err
 	}
@@ -120,6 +121,11 @@ func (c *Client) SetNewRecordOp(userID string, parent *Block, recordType string)
 
 // UploadFile Uploads a file to notion's asset hosting(aws s3)
 func (c *Client) UploadFile(file *os.File) (fileID, fileURL string, err error) {
+	return c.UploadFileCtx(context.Background(), file)
+}
+
+// UploadFileCtx is like UploadFile but can be cancelled with ctx
+func (c *Client) UploadFileCtx(ctx context.Context, file *os.File) (fileID, fileURL string, err error) {
 	contentType, err := GetFileContentType(file)
 	c.logf("contentType: %s", contentType)
 
@@ -137,7 +143,7 @@ func (c *Client) UploadFile(file *os.File) (fileID, fileURL string, err error) {
 	fileSize := fi.Size()
 
 	// 1. getUploadFileURL
-	uploadFileURLResp, err := c.getUploadFileURL(file.Name(), contentType)
+	uploadFileURLResp, err := c.getUploadFileURL(ctx, file.Name(), contentType)
 	if err != nil {
 		err = fmt.Errorf("get upload file URL error: %s", err)
 		return
@@ -146,7 +152,7 @@ func (c *Client) UploadFile(file *os.File) (fileID, fileURL string, err error) {
 	// 2. Upload file to amazon - PUT
 	httpClient := c.getHTTPClient()
 
-	req, err := http.NewRequest(http.MethodPut, uploadFileURLResp.SignedPutURL, file)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadFileURLResp.SignedPutURL, file)
 	if err != nil {
 		return
 	}
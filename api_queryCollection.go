@@ -1,6 +1,7 @@
 package notionapi
 
 import (
+	"context"
 	"net/url"
 )
 
@@ -131,6 +132,11 @@ func MakeLoaderReducer(query *Query, limits ...int) *LoaderReducer {
 
 // QueryCollection executes a raw API call /api/v3/queryCollection
 func (c *Client) QueryCollection(req QueryCollectionRequest, query *Query, params ...map[string]string) (*QueryCollectionResponse, error) {
+	return c.QueryCollectionCtx(context.Background(), req, query, params...)
+}
+
+// QueryCollectionCtx is like QueryCollection but can be cancelled with ctx
+func (c *Client) QueryCollectionCtx(ctx context.Context, req QueryCollectionRequest, query *Query, params ...map[string]string) (*QueryCollectionResponse, error) {
 	if req.Loader == nil {
 		req.Loader = MakeLoaderReducer(query)
 	}
@@ -146,11 +152,11 @@ func (c *Client) QueryCollection(req QueryCollectionRequest, query *Query, param
 	if len(values) > 0 {
 		apiURL += "?" + values.Encode()
 	}
-	err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON)
+	err = c.doNotionAPI(ctx, apiURL, req, &rsp, &rsp.RawJSON)
 	if err != nil {
 		return nil, err
 	}
-	// TODO: fetch more if exceeded limit
+	// Client.FetchAllTableRows fetches more if exceeded limit
 	if err := ParseRecordMap(rsp.RecordMap); err != nil {
 		return nil, err
 	}
@@ -159,6 +165,11 @@ func (c *Client) QueryCollection(req QueryCollectionRequest, query *Query, param
 
 // QuerySpaceShortId executes a raw API call /api/v3/getPublicPageData
 func (c *Client) QuerySpaceShortId(pageId string, collectionViewID string) (*QueryPageShortIdResponse, error) {
+	return c.QuerySpaceShortIdCtx(context.Background(), pageId, collectionViewID)
+}
+
+// QuerySpaceShortIdCtx is like QuerySpaceShortId but can be cancelled with ctx
+func (c *Client) QuerySpaceShortIdCtx(ctx context.Context, pageId string, collectionViewID string) (*QueryPageShortIdResponse, error) {
 	req := QueryPageShortIdRequest{
 		BlockID:                   pageId,
 		Name:                      "page",
@@ -181,7 +192,7 @@ func (c *Client) QuerySpaceShortId(pageId string, collectionViewID string) (*Que
 	var rsp QueryPageShortIdResponse
 	var err error
 	apiURL := "/api/v3/getPublicPageData"
-	err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON)
+	err = c.doNotionAPI(ctx, apiURL, req, &rsp, &rsp.RawJSON)
 	if err != nil {
 		return nil, err
 	}
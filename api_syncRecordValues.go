@@ -1,5 +1,7 @@
 package notionapi
 
+import "context"
+
 // /api/v3/syncRecordValues request
 type syncRecordRequest struct {
 	Requests []PointerWithVersion `json:"requests"`
@@ -25,10 +27,15 @@ type SyncRecordValuesResponse struct {
 
 // SyncRecordValues executes a raw API call /api/v3/syncRecordValues
 func (c *Client) SyncRecordValues(req syncRecordRequest) (*SyncRecordValuesResponse, error) {
+	return c.SyncRecordValuesCtx(context.Background(), req)
+}
+
+// SyncRecordValuesCtx is like SyncRecordValues but can be cancelled with ctx
+func (c *Client) SyncRecordValuesCtx(ctx context.Context, req syncRecordRequest) (*SyncRecordValuesResponse, error) {
 	var rsp SyncRecordValuesResponse
 	var err error
 	apiURL := "/api/v3/syncRecordValues"
-	if err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON); err != nil {
+	if err = c.doNotionAPI(ctx, apiURL, req, &rsp, &rsp.RawJSON); err != nil {
 		return nil, err
 	}
 	if err = ParseRecordMap(rsp.RecordMap); err != nil {
@@ -42,6 +49,11 @@ func (c *Client) SyncRecordValues(req syncRecordRequest) (*SyncRecordValuesRespo
 // Used to retrieve version information for each block so that we can skip re-downloading pages
 // that didn't change
 func (c *Client) GetBlockRecords(ids []string) ([]*Block, error) {
+	return c.GetBlockRecordsCtx(context.Background(), ids)
+}
+
+// GetBlockRecordsCtx is like GetBlockRecords but can be cancelled with ctx
+func (c *Client) GetBlockRecordsCtx(ctx context.Context, ids []string) ([]*Block, error) {
 	var req syncRecordRequest
 	for _, id := range ids {
 		id = ToDashID(id)
@@ -56,7 +68,7 @@ func (c *Client) GetBlockRecords(ids []string) ([]*Block, error) {
 		req.Requests = append(req.Requests, pver)
 	}
 
-	rsp, err := c.SyncRecordValues(req)
+	rsp, err := c.SyncRecordValuesCtx(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -75,3 +87,77 @@ func (c *Client) GetBlockRecords(ids []string) ([]*Block, error) {
 	}
 	return res, nil
 }
+
+// GetCollectionRecords returns Collection records with given ids.
+// Result has the same length as ids, with nil for collections
+// that were not returned
+func (c *Client) GetCollectionRecords(ids []string) ([]*Collection, error) {
+	return c.GetCollectionRecordsCtx(context.Background(), ids)
+}
+
+// GetCollectionRecordsCtx is like GetCollectionRecords but can be cancelled with ctx
+func (c *Client) GetCollectionRecordsCtx(ctx context.Context, ids []string) ([]*Collection, error) {
+	var req syncRecordRequest
+	for _, id := range ids {
+		pver := PointerWithVersion{
+			Pointer: Pointer{
+				ID:    ToDashID(id),
+				Table: TableCollection,
+			},
+			Version: -1,
+		}
+		req.Requests = append(req.Requests, pver)
+	}
+
+	rsp, err := c.SyncRecordValuesCtx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var res []*Collection
+	rm := rsp.RecordMap
+	for _, id := range ids {
+		var collection *Collection
+		if r := rm.Collections[ToDashID(id)]; r != nil {
+			collection = r.Collection
+		}
+		res = append(res, collection)
+	}
+	return res, nil
+}
+
+// GetCollectionViewRecords returns CollectionView records with given ids.
+// Result has the same length as ids, with nil for views
+// that were not returned
+func (c *Client) GetCollectionViewRecords(ids []string) ([]*CollectionView, error) {
+	return c.GetCollectionViewRecordsCtx(context.Background(), ids)
+}
+
+// GetCollectionViewRecordsCtx is like GetCollectionViewRecords but can be cancelled with ctx
+func (c *Client) GetCollectionViewRecordsCtx(ctx context.Context, ids []string) ([]*CollectionView, error) {
+	var req syncRecordRequest
+	for _, id := range ids {
+		pver := PointerWithVersion{
+			Pointer: Pointer{
+				ID:    ToDashID(id),
+				Table: TableCollectionView,
+			},
+			Version: -1,
+		}
+		req.Requests = append(req.Requests, pver)
+	}
+
+	rsp, err := c.SyncRecordValuesCtx(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var res []*CollectionView
+	rm := rsp.RecordMap
+	for _, id := range ids {
+		var view *CollectionView
+		if r := rm.CollectionViews[ToDashID(id)]; r != nil {
+			view = r.CollectionView
+		}
+		res = append(res, view)
+	}
+	return res, nil
+}
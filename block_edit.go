@@ -0,0 +1,240 @@
+package notionapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// fields of a block record that are not copied by DuplicateBlockTree
+var blockFieldsNotCopied = []string{
+	"created_by",
+	"created_by_id",
+	"created_by_table",
+	"last_edited_by",
+	"last_edited_by_id",
+	"last_edited_by_table",
+	"discussion",
+	"permissions",
+}
+
+// moveBlockOps returns operations that move block b to be a child
+// of newParentID, after afterID (at the end if afterID is "")
+func moveBlockOps(b *Block, newParentID string, afterID string) []*Operation {
+	var ops []*Operation
+	if b.ParentTable == TableBlock && b.ParentID != "" {
+		oldParent := &Block{ID: b.ParentID}
+		ops = append(ops, oldParent.ListRemoveContentOp(b.ID))
+	}
+	newParent := &Block{ID: newParentID}
+	ops = append(ops, b.SetParentOp(newParentID, TableBlock))
+	ops = append(ops, newParent.ListAfterContentOp(b.ID, afterID))
+	return ops
+}
+
+// archiveBlockOps returns operations that soft-delete block b
+func archiveBlockOps(b *Block) []*Operation {
+	ops := []*Operation{b.SetAliveOp(false)}
+	if b.ParentTable == TableBlock && b.ParentID != "" {
+		parent := &Block{ID: b.ParentID}
+		ops = append(ops, parent.ListRemoveContentOp(b.ID))
+	}
+	return ops
+}
+
+// restoreBlockOps returns operations that restore soft-deleted block b.
+// It's added at the end of its parent's content
+func restoreBlockOps(b *Block) []*Operation {
+	ops := []*Operation{b.SetAliveOp(true)}
+	if b.ParentTable == TableBlock && b.ParentID != "" {
+		parent := &Block{ID: b.ParentID}
+		ops = append(ops, parent.ListAfterContentOp(b.ID, ""))
+	}
+	return ops
+}
+
+// MoveBlock moves a block to be a child of newParentID block, after
+// afterID block (at the end if afterID is ""). newParentID can't be
+// the block or one of its descendants
+func (c *Client) MoveBlock(blockID string, newParentID string, afterID string) error {
+	return c.MoveBlockCtx(context.Background(), blockID, newParentID, afterID)
+}
+
+// MoveBlockCtx is like MoveBlock but can be cancelled with ctx
+func (c *Client) MoveBlockCtx(ctx context.Context, blockID string, newParentID string, afterID string) error {
+	blockID = ToDashID(blockID)
+	newParentID = ToDashID(newParentID)
+	if afterID != "" {
+		afterID = ToDashID(afterID)
+	}
+	if blockID == newParentID {
+		return fmt.Errorf("can't move block '%s' into itself", blockID)
+	}
+	b, err := c.getBlock(ctx, blockID)
+	if err != nil {
+		return err
+	}
+	newParent, err := c.getBlock(ctx, newParentID)
+	if err != nil {
+		return err
+	}
+	// moving a block under its descendant would detach it from the page
+	isDescendant := false
+	err = c.walkParents(ctx, newParent, func(p *Block) bool {
+		isDescendant = isIDEqual(p.ID, blockID)
+		return !isDescendant
+	})
+	if err != nil {
+		return err
+	}
+	if isDescendant {
+		return fmt.Errorf("can't move block '%s' into its descendant '%s'", blockID, newParentID)
+	}
+	tx := NewTransaction().Add(moveBlockOps(b, newParentID, afterID)...)
+	return c.CommitTransactionCtx(ctx, tx)
+}
+
+// ArchiveBlock soft-deletes a block (sets alive to false) and
+// removes it from its parent's content. It can be undone with RestoreBlock
+func (c *Client) ArchiveBlock(blockID string) error {
+	return c.ArchiveBlockCtx(context.Background(), blockID)
+}
+
+// ArchiveBlockCtx is like ArchiveBlock but can be cancelled with ctx
+func (c *Client) ArchiveBlockCtx(ctx context.Context, blockID string) error {
+	b, err := c.getBlock(ctx, ToDashID(blockID))
+	if err != nil {
+		return err
+	}
+	tx := NewTransaction().Add(archiveBlockOps(b)...)
+	return c.CommitTransactionCtx(ctx, tx)
+}
+
+// RestoreBlock restores a block deleted with ArchiveBlock. The block
+// is added at the end of its parent's content
+func (c *Client) RestoreBlock(blockID string) error {
+	return c.RestoreBlockCtx(context.Background(), blockID)
+}
+
+// RestoreBlockCtx is like RestoreBlock but can be cancelled with ctx
+func (c *Client) RestoreBlockCtx(ctx context.Context, blockID string) error {
+	b, err := c.getBlock(ctx, ToDashID(blockID))
+	if err != nil {
+		return err
+	}
+	tx := NewTransaction().Add(restoreBlockOps(b)...)
+	return c.CommitTransactionCtx(ctx, tx)
+}
+
+// blockDuplicator adds operations that deep-copy a tree of blocks to a Transaction
+type blockDuplicator struct {
+	tx      *Transaction
+	spaceID string
+	// generates ids of new blocks, can be over-written in tests
+	newID func() string
+	// downloads sub-pages, whose content is not part of the Page
+	loadPage func(pageID string) (*Page, error)
+}
+
+// copyBlock adds ops that create copies of children of b and returns id of
+// the copy of b and the op that creates it, with content set to the copied
+// children. The caller adds the op after the children, so that if the
+// transaction is split, a committed block never refers to missing children
+func (d *blockDuplicator) copyBlock(b *Block, parentID string, isRoot bool) (string, *Operation, error) {
+	if b.Type == BlockPage && !isRoot {
+		// content of sub-pages is not downloaded with the page
+		page, err := d.loadPage(b.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		b = page.Root()
+	}
+	id := d.newID()
+	args := map[string]interface{}{}
+	for k, v := range b.RawJSON {
+		args[k] = v
+	}
+	for _, k := range blockFieldsNotCopied {
+		delete(args, k)
+	}
+	args["id"] = id
+	args["version"] = 1
+	args["alive"] = true
+	args["type"] = b.Type
+	args["parent_id"] = parentID
+	args["parent_table"] = TableBlock
+	args["space_id"] = d.spaceID
+	args["copied_from"] = b.ID
+	args["created_time"] = d.tx.now
+	args["last_edited_time"] = d.tx.now
+	if b.Properties != nil {
+		args["properties"] = b.Properties
+	}
+	delete(args, "content")
+
+	var contentIDs []string
+	for _, child := range b.Content {
+		// content can refer to blocks that live elsewhere
+		if child.ParentID != b.ID {
+			continue
+		}
+		childID, op, err := d.copyBlock(child, id, false)
+		if err != nil {
+			return "", nil, err
+		}
+		d.tx.Add(op)
+		contentIDs = append(contentIDs, childID)
+	}
+	if len(contentIDs) > 0 {
+		args["content"] = contentIDs
+	}
+	copied := &Block{ID: id}
+	return id, copied.buildOp(CommandSet, []string{}, args), nil
+}
+
+// DuplicateBlockTree creates a deep copy of a block (usually a page), including
+// all children and sub-pages, as the last child of targetParentID block.
+// Copies have new ids and copied_from set to the id of the original.
+// Returns id of the copy.
+//
+// Collection views in the copy show the same collections as the originals.
+func (c *Client) DuplicateBlockTree(blockID string, targetParentID string) (string, error) {
+	return c.DuplicateBlockTreeCtx(context.Background(), blockID, targetParentID)
+}
+
+// DuplicateBlockTreeCtx is like DuplicateBlockTree but can be cancelled with ctx
+func (c *Client) DuplicateBlockTreeCtx(ctx context.Context, blockID string, targetParentID string) (string, error) {
+	targetParentID = ToDashID(targetParentID)
+	target, err := c.getBlock(ctx, targetParentID)
+	if err != nil {
+		return "", err
+	}
+	opts := &DownloadOptions{SkipCollections: true}
+	loadPage := func(pageID string) (*Page, error) {
+		return c.DownloadPageWithOptionsCtx(ctx, pageID, opts)
+	}
+	page, err := loadPage(blockID)
+	if err != nil {
+		return "", err
+	}
+	tx := NewTransaction()
+	d := &blockDuplicator{
+		tx:      tx,
+		spaceID: target.SpaceID,
+		newID: func() string {
+			return uuid.New().String()
+		},
+		loadPage: loadPage,
+	}
+	id, op, err := d.copyBlock(page.Root(), targetParentID, true)
+	if err != nil {
+		return "", err
+	}
+	// the copy is added to the target in the same request
+	tx.Add(op, target.ListAfterContentOp(id, ""))
+	if err = c.CommitTransactionCtx(ctx, tx); err != nil {
+		return "", err
+	}
+	return id, nil
+}
@@ -0,0 +1,179 @@
+package notionapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+// newFakeBlocksClient returns a Client that serves blocks from a given
+// map of block id to block json and records submitted operations
+func newFakeBlocksClient(t *testing.T, blocks map[string]interface{}, submitted *[]*Operation) *Client {
+	return &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			if strings.Contains(uri, "/api/v3/syncRecordValues") {
+				var req syncRecordRequest
+				assert.NoError(t, json.Unmarshal(body, &req))
+				records := map[string]interface{}{}
+				for _, r := range req.Requests {
+					id := r.Pointer.ID
+					records[id] = map[string]interface{}{"role": "editor", "value": blocks[id]}
+				}
+				return json.Marshal(map[string]interface{}{"recordMap": map[string]interface{}{"block": records}})
+			}
+			var req submitTransactionRequest
+			err := json.Unmarshal(body, &req)
+			*submitted = append(*submitted, req.Operations...)
+			return []byte("{}"), err
+		},
+	}
+}
+
+func TestMoveBlockIntoDescendant(t *testing.T) {
+	block := func(id string, parentID string) map[string]interface{} {
+		return map[string]interface{}{"id": id, "type": "text", "alive": true, "parent_id": parentID, "parent_table": "block"}
+	}
+	blocks := map[string]interface{}{
+		testBlockID(1): map[string]interface{}{"id": testBlockID(1), "type": "page", "alive": true},
+		testBlockID(2): block(testBlockID(2), testBlockID(1)),
+		testBlockID(3): block(testBlockID(3), testBlockID(2)),
+		testBlockID(4): block(testBlockID(4), testBlockID(3)),
+	}
+	var submitted []*Operation
+	c := newFakeBlocksClient(t, blocks, &submitted)
+	err := c.MoveBlock(testBlockID(2), ToNoDashID(testBlockID(4)), "")
+	assert.Error(t, err)
+	err = c.MoveBlock(testBlockID(2), testBlockID(3), "")
+	assert.Error(t, err)
+	assert.Equal(t, 0, len(submitted))
+
+	err = c.MoveBlock(testBlockID(4), testBlockID(1), "")
+	assert.NoError(t, err)
+	assert.True(t, len(submitted) > 0)
+}
+
+func TestMoveBlockOps(t *testing.T) {
+	b := &Block{ID: testBlockID(3), ParentID: testBlockID(1), ParentTable: TableBlock}
+	tx := NewTransaction().Add(moveBlockOps(b, testBlockID(2), testBlockID(4))...)
+	assert.NoError(t, tx.Validate())
+	ops := tx.Operations()
+	// 3 ops + last edited time of old parent, block and new parent
+	assert.Equal(t, 6, len(ops))
+
+	assert.Equal(t, CommandListRemove, ops[0].Command)
+	assert.Equal(t, testBlockID(1), ops[0].ID)
+	assert.Equal(t, map[string]string{"id": b.ID}, ops[0].Args)
+
+	assert.Equal(t, CommandUpdate, ops[1].Command)
+	assert.Equal(t, b.ID, ops[1].ID)
+	exp := map[string]interface{}{"parent_id": testBlockID(2), "parent_table": TableBlock}
+	assert.Equal(t, exp, ops[1].Args)
+
+	assert.Equal(t, CommandListAfter, ops[2].Command)
+	assert.Equal(t, testBlockID(2), ops[2].ID)
+	assert.Equal(t, map[string]string{"id": b.ID, "after": testBlockID(4)}, ops[2].Args)
+
+	// top-level page is not in content of a block
+	b.ParentTable = TableSpace
+	assert.Equal(t, 2, len(moveBlockOps(b, testBlockID(2), "")))
+}
+
+func TestArchiveRestoreBlockOps(t *testing.T) {
+	b := &Block{ID: testBlockID(3), ParentID: testBlockID(1), ParentTable: TableBlock}
+	ops := archiveBlockOps(b)
+	assert.Equal(t, 2, len(ops))
+	assert.Equal(t, map[string]interface{}{"alive": false}, ops[0].Args)
+	assert.Equal(t, CommandListRemove, ops[1].Command)
+	assert.Equal(t, testBlockID(1), ops[1].ID)
+
+	ops = restoreBlockOps(b)
+	assert.Equal(t, 2, len(ops))
+	assert.Equal(t, map[string]interface{}{"alive": true}, ops[0].Args)
+	assert.Equal(t, CommandListAfter, ops[1].Command)
+	assert.Equal(t, map[string]string{"id": b.ID}, ops[1].Args)
+}
+
+func TestDuplicateBlockTree(t *testing.T) {
+	page := newTestPage(t, &testBlock{
+		Type:  BlockPage,
+		Props: titleProp("Page", ""),
+		Children: []*testBlock{
+			{Type: BlockText, Props: titleProp("text", "")},
+			{Type: BlockToggle, Props: titleProp("toggle", ""), Children: []*testBlock{
+				{Type: BlockCallout, Props: titleProp("callout", ""), Format: map[string]interface{}{"page_icon": "💡"}},
+			}},
+			{Type: BlockPage, Props: titleProp("Sub page", "")},
+		},
+	})
+	subPage := newTestPage(t, &testBlock{
+		Type:     BlockPage,
+		Props:    titleProp("Sub page", ""),
+		Children: []*testBlock{{Type: BlockText, Props: titleProp("sub page text", "")}},
+	})
+
+	tx := NewTransaction()
+	n := 100
+	var loaded []string
+	d := &blockDuplicator{
+		tx:      tx,
+		spaceID: testSpaceID,
+		newID: func() string {
+			n++
+			return testBlockID(n)
+		},
+		loadPage: func(pageID string) (*Page, error) {
+			loaded = append(loaded, pageID)
+			return subPage, nil
+		},
+	}
+	targetID := testBlockID(99)
+	id, op, err := d.copyBlock(page.Root(), targetID, true)
+	assert.NoError(t, err)
+	tx.Add(op)
+	assert.Equal(t, testBlockID(101), id)
+	assert.Equal(t, []string{testBlockID(5)}, loaded)
+	assert.NoError(t, tx.Validate())
+
+	// each block is sent after its children, so that a split transaction
+	// doesn't create blocks with missing children
+	ops := tx.Operations()
+	// 6 copied blocks and last edited time of target, which is sent
+	// together with the copy of the root
+	assert.Equal(t, 7, len(ops))
+	assert.Equal(t, targetID, ops[6].ID)
+	assert.Equal(t, CommandUpdate, ops[6].Command)
+	ops = ops[:6]
+	var order []string
+	for _, op := range ops {
+		order = append(order, op.ID)
+	}
+	exp := []string{testBlockID(102), testBlockID(104), testBlockID(103), testBlockID(106), testBlockID(105), testBlockID(101)}
+	assert.Equal(t, exp, order)
+	copyOf := map[string]string{}
+	for _, op := range ops {
+		assert.Equal(t, CommandSet, op.Command)
+		args := op.Args.(map[string]interface{})
+		assert.Equal(t, op.ID, args["id"])
+		assert.Equal(t, testSpaceID, args["space_id"])
+		copyOf[op.ID] = args["copied_from"].(string)
+	}
+	assert.Equal(t, testBlockID(1), copyOf[testBlockID(101)])
+	assert.Equal(t, testBlockID(4), copyOf[testBlockID(104)])
+
+	root := ops[5].Args.(map[string]interface{})
+	assert.Equal(t, targetID, root["parent_id"])
+	assert.Equal(t, []string{testBlockID(102), testBlockID(103), testBlockID(105)}, root["content"])
+	assert.Equal(t, titleProp("Page", ""), root["properties"])
+
+	callout := ops[1].Args.(map[string]interface{})
+	assert.Equal(t, testBlockID(103), callout["parent_id"])
+	assert.Equal(t, map[string]interface{}{"page_icon": "💡"}, callout["format"])
+
+	sub := ops[4].Args.(map[string]interface{})
+	assert.Equal(t, []string{testBlockID(106)}, sub["content"])
+	assert.Equal(t, testBlockID(105), ops[3].Args.(map[string]interface{})["parent_id"])
+}
@@ -0,0 +1,373 @@
+package notionapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// BlockSpec describes a block to be created with Client.CreatePage
+// or Client.AppendBlocks. Use NewTextSpec, NewHeaderSpec etc. to create them
+type BlockSpec struct {
+	// Type is one of the Block* constants e.g. BlockText
+	Type string
+	// Title is the text of the block
+	Title []*TextSpan
+	// for BlockTodo
+	Checked bool
+	// for BlockCode e.g. "Go"
+	Language string
+	// for BlockCallout, an emoji
+	Icon string
+	// for BlockImage and BlockFile, url of the image / file
+	Source string
+	// Children are nested blocks e.g. nested list items
+	Children []BlockSpec
+}
+
+// block types that can be created from BlockSpec
+var creatableBlockTypes = map[string]bool{
+	BlockPage:         true,
+	BlockText:         true,
+	BlockHeader:       true,
+	BlockSubHeader:    true,
+	BlockSubSubHeader: true,
+	BlockBulletedList: true,
+	BlockNumberedList: true,
+	BlockTodo:         true,
+	BlockToggle:       true,
+	BlockCode:         true,
+	BlockQuote:        true,
+	BlockCallout:      true,
+	BlockDivider:      true,
+	BlockImage:        true,
+	BlockFile:         true,
+}
+
+func plainText(s string) []*TextSpan {
+	if s == "" {
+		return nil
+	}
+	return []*TextSpan{{Text: s}}
+}
+
+// NewTextSpec returns a spec of BlockText
+func NewTextSpec(s string, children ...BlockSpec) BlockSpec {
+	return BlockSpec{Type: BlockText, Title: plainText(s), Children: children}
+}
+
+// NewRichTextSpec returns a spec of BlockText with formatted text
+func NewRichTextSpec(rt *RichText, children ...BlockSpec) BlockSpec {
+	return BlockSpec{Type: BlockText, Title: rt.Spans, Children: children}
+}
+
+// NewHeaderSpec returns a spec of BlockHeader (level 1), BlockSubHeader (level 2)
+// or BlockSubSubHeader (level 3)
+func NewHeaderSpec(level int, s string) BlockSpec {
+	typ := BlockHeader
+	switch level {
+	case 2:
+		typ = BlockSubHeader
+	case 3:
+		typ = BlockSubSubHeader
+	}
+	return BlockSpec{Type: typ, Title: plainText(s)}
+}
+
+// NewBulletedListSpec returns a spec of BlockBulletedList
+func NewBulletedListSpec(s string, children ...BlockSpec) BlockSpec {
+	return BlockSpec{Type: BlockBulletedList, Title: plainText(s), Children: children}
+}
+
+// NewNumberedListSpec returns a spec of BlockNumberedList
+func NewNumberedListSpec(s string, children ...BlockSpec) BlockSpec {
+	return BlockSpec{Type: BlockNumberedList, Title: plainText(s), Children: children}
+}
+
+// NewTodoSpec returns a spec of BlockTodo
+func NewTodoSpec(s string, checked bool, children ...BlockSpec) BlockSpec {
+	return BlockSpec{Type: BlockTodo, Title: plainText(s), Checked: checked, Children: children}
+}
+
+// NewToggleSpec returns a spec of BlockToggle
+func NewToggleSpec(s string, children ...BlockSpec) BlockSpec {
+	return BlockSpec{Type: BlockToggle, Title: plainText(s), Children: children}
+}
+
+// NewCodeSpec returns a spec of BlockCode. language is e.g. "Go"
+func NewCodeSpec(code string, language string) BlockSpec {
+	return BlockSpec{Type: BlockCode, Title: plainText(code), Language: language}
+}
+
+// NewQuoteSpec returns a spec of BlockQuote
+func NewQuoteSpec(s string) BlockSpec {
+	return BlockSpec{Type: BlockQuote, Title: plainText(s)}
+}
+
+// NewCalloutSpec returns a spec of BlockCallout. icon is an emoji
+func NewCalloutSpec(s string, icon string) BlockSpec {
+	return BlockSpec{Type: BlockCallout, Title: plainText(s), Icon: icon}
+}
+
+// NewDividerSpec returns a spec of BlockDivider
+func NewDividerSpec() BlockSpec {
+	return BlockSpec{Type: BlockDivider}
+}
+
+// NewImageSpec returns a spec of BlockImage
+func NewImageSpec(uri string) BlockSpec {
+	return BlockSpec{Type: BlockImage, Source: uri}
+}
+
+// NewFileSpec returns a spec of BlockFile
+func NewFileSpec(uri string, name string) BlockSpec {
+	return BlockSpec{Type: BlockFile, Source: uri, Title: plainText(name)}
+}
+
+func (s *BlockSpec) validate() error {
+	if !creatableBlockTypes[s.Type] {
+		return fmt.Errorf("can't create block of type '%s'", s.Type)
+	}
+	if (s.Type == BlockImage || s.Type == BlockFile) && s.Source == "" {
+		return fmt.Errorf("block of type '%s' must have Source", s.Type)
+	}
+	if _, err := TextSpansToRaw(s.Title); err != nil {
+		return fmt.Errorf("block of type '%s': %w", s.Type, err)
+	}
+	for i := range s.Children {
+		if err := s.Children[i].validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BlockSpec) properties() map[string]interface{} {
+	props := map[string]interface{}{}
+	if len(s.Title) > 0 {
+		// validate checked that the title can be converted
+		props["title"], _ = TextSpansToRaw(s.Title)
+	}
+	switch s.Type {
+	case BlockTodo:
+		checked := "No"
+		if s.Checked {
+			checked = "Yes"
+		}
+		props["checked"] = [][]string{{checked}}
+	case BlockCode:
+		if s.Language != "" {
+			props["language"] = [][]string{{s.Language}}
+		}
+	case BlockImage, BlockFile:
+		props["source"] = [][]string{{s.Source}}
+	}
+	return props
+}
+
+func (s *BlockSpec) format() map[string]interface{} {
+	format := map[string]interface{}{}
+	switch s.Type {
+	case BlockCallout:
+		if s.Icon != "" {
+			format["page_icon"] = s.Icon
+		}
+	case BlockImage:
+		format["display_source"] = s.Source
+	}
+	return format
+}
+
+// blockOpsBuilder adds operations that create blocks from BlockSpec to a Transaction
+type blockOpsBuilder struct {
+	tx      *Transaction
+	spaceID string
+	// generates ids of new blocks, can be over-written in tests
+	newID func() string
+}
+
+func newBlockOpsBuilder(tx *Transaction, spaceID string) *blockOpsBuilder {
+	return &blockOpsBuilder{
+		tx:      tx,
+		spaceID: spaceID,
+		newID: func() string {
+			return uuid.New().String()
+		},
+	}
+}
+
+// addBlock adds ops that create a block (and its children) as
+// a child of parentID, after afterID. Returns id of the new block
+func (b *blockOpsBuilder) addBlock(parentID string, afterID string, spec *BlockSpec) string {
+	block := &Block{
+		ID: b.newID(),
+	}
+	args := map[string]interface{}{
+		"id":               block.ID,
+		"version":          1,
+		"alive":            true,
+		"type":             spec.Type,
+		"parent_id":        parentID,
+		"parent_table":     TableBlock,
+		"space_id":         b.spaceID,
+		"created_time":     b.tx.now,
+		"last_edited_time": b.tx.now,
+	}
+	if props := spec.properties(); len(props) > 0 {
+		args["properties"] = props
+	}
+	if format := spec.format(); len(format) > 0 {
+		args["format"] = format
+	}
+	parent := &Block{ID: parentID}
+	b.tx.Add(
+		block.buildOp(CommandSet, []string{}, args),
+		parent.ListAfterContentOp(block.ID, afterID),
+	)
+	b.addBlocks(block.ID, "", spec.Children)
+	return block.ID
+}
+
+// addBlocks adds ops that create blocks as children of parentID, after
+// afterID (at the end if afterID is ""). Returns ids of new blocks
+func (b *blockOpsBuilder) addBlocks(parentID string, afterID string, specs []BlockSpec) []string {
+	var ids []string
+	for i := range specs {
+		id := b.addBlock(parentID, afterID, &specs[i])
+		ids = append(ids, id)
+		afterID = id
+	}
+	return ids
+}
+
+// getBlock returns a block with a given id or an error if it doesn't exist
+func (c *Client) getBlock(ctx context.Context, blockID string) (*Block, error) {
+	blocks, err := c.GetBlockRecordsCtx(ctx, []string{blockID})
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil, fmt.Errorf("block '%s' doesn't exist or is not accessible", blockID)
+	}
+	return blocks[0], nil
+}
+
+// walkParents calls fn with b and then with its ancestor blocks, from
+// the closest, until fn returns false or there are no more parent blocks
+func (c *Client) walkParents(ctx context.Context, b *Block, fn func(*Block) bool) error {
+	seen := map[string]bool{}
+	for fn(b) {
+		if b.ParentTable != TableBlock || b.ParentID == "" {
+			return nil
+		}
+		parentID := ToDashID(b.ParentID)
+		if seen[parentID] {
+			return fmt.Errorf("block '%s' is its own ancestor", parentID)
+		}
+		seen[parentID] = true
+		var err error
+		if b, err = c.getBlock(ctx, parentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// containingPageID returns id of the page that contains block b (b itself
+// if it's a page) or "" if b is not inside a page
+func (c *Client) containingPageID(ctx context.Context, b *Block) (string, error) {
+	pageID := ""
+	err := c.walkParents(ctx, b, func(b *Block) bool {
+		if b.Type == BlockPage || b.Type == BlockCollectionViewPage {
+			pageID = b.ID
+			return false
+		}
+		return true
+	})
+	return pageID, err
+}
+
+// CreatePage creates a new page with a given title and content as
+// a child of parentID block (usually a page). Returns id of the new page.
+// If the error is *CommitError, the id is returned if the page was
+// created, even though some of its content might not be
+func (c *Client) CreatePage(parentID string, title string, children []BlockSpec) (string, error) {
+	return c.CreatePageCtx(context.Background(), parentID, title, children)
+}
+
+// CreatePageCtx is like CreatePage but can be cancelled with ctx
+func (c *Client) CreatePageCtx(ctx context.Context, parentID string, title string, children []BlockSpec) (string, error) {
+	spec := BlockSpec{
+		Type:     BlockPage,
+		Title:    plainText(title),
+		Children: children,
+	}
+	ids, err := c.AppendBlocksCtx(ctx, parentID, "", []BlockSpec{spec})
+	if len(ids) == 0 {
+		return "", err
+	}
+	return ids[0], err
+}
+
+// AppendBlocks creates blocks as children of parentID block, after afterID
+// block (at the end if afterID is ""). Returns ids of new top-level blocks.
+//
+// Many blocks can be sent in multiple requests (see CommitTransaction).
+// If one of them fails, the error is *CommitError and returned ids are
+// of top-level blocks that were created (their children might not be)
+func (c *Client) AppendBlocks(parentID string, afterID string, children []BlockSpec) ([]string, error) {
+	return c.AppendBlocksCtx(context.Background(), parentID, afterID, children)
+}
+
+// AppendBlocksCtx is like AppendBlocks but can be cancelled with ctx
+func (c *Client) AppendBlocksCtx(ctx context.Context, parentID string, afterID string, children []BlockSpec) ([]string, error) {
+	if len(children) == 0 {
+		return nil, errors.New("no blocks to create")
+	}
+	for i := range children {
+		if err := children[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	parentID = ToDashID(parentID)
+	if afterID != "" {
+		afterID = ToDashID(afterID)
+	}
+	parent, err := c.getBlock(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction()
+	if tx.PageID, err = c.containingPageID(ctx, parent); err != nil {
+		return nil, err
+	}
+	b := newBlockOpsBuilder(tx, parent.SpaceID)
+	ids := b.addBlocks(parentID, afterID, children)
+	if err = c.CommitTransactionCtx(ctx, tx); err != nil {
+		var commitErr *CommitError
+		if errors.As(err, &commitErr) {
+			return committedBlockIDs(ids, commitErr.Operations), err
+		}
+		return nil, err
+	}
+	return ids, nil
+}
+
+// committedBlockIDs returns ids of blocks created by ops
+func committedBlockIDs(ids []string, ops []*Operation) []string {
+	created := map[string]bool{}
+	for _, op := range ops {
+		if op.Table == TableBlock && op.Command == CommandSet && len(op.Path) == 0 {
+			created[op.ID] = true
+		}
+	}
+	var res []string
+	for _, id := range ids {
+		if created[id] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
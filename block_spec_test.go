@@ -0,0 +1,225 @@
+package notionapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+const testSpaceID = "00000000-0000-0000-0000-0000000000aa"
+
+func newTestBlockOpsBuilder() *blockOpsBuilder {
+	tx := NewTransaction()
+	tx.now = 1600000000000
+	b := newBlockOpsBuilder(tx, testSpaceID)
+	n := 100
+	b.newID = func() string {
+		n++
+		return testBlockID(n)
+	}
+	return b
+}
+
+func TestBlockOpsBuilder(t *testing.T) {
+	parentID := testBlockID(1)
+	specs := []BlockSpec{
+		NewHeaderSpec(2, "Header"),
+		NewBulletedListSpec("item", NewTodoSpec("todo", true)),
+		NewCodeSpec("fmt.Println()", "Go"),
+		NewCalloutSpec("note", "💡"),
+		NewImageSpec("https://example.com/img.png"),
+	}
+	b := newTestBlockOpsBuilder()
+	ids := b.addBlocks(parentID, testBlockID(2), specs)
+	assert.Equal(t, []string{testBlockID(101), testBlockID(102), testBlockID(104), testBlockID(105), testBlockID(106)}, ids)
+
+	// 6 blocks * (set + listAfter) + last edited time of parent
+	ops := b.tx.Operations()
+	assert.Equal(t, 13, len(ops))
+
+	op := ops[0]
+	assert.Equal(t, CommandSet, op.Command)
+	assert.Equal(t, testBlockID(101), op.ID)
+	args := op.Args.(map[string]interface{})
+	assert.Equal(t, BlockSubHeader, args["type"])
+	assert.Equal(t, parentID, args["parent_id"])
+	assert.Equal(t, testSpaceID, args["space_id"])
+
+	op = ops[1]
+	assert.Equal(t, CommandListAfter, op.Command)
+	assert.Equal(t, parentID, op.ID)
+	assert.Equal(t, map[string]string{"id": testBlockID(101), "after": testBlockID(2)}, op.Args)
+
+	// last edited time of parent is sent with the first block added to it
+	op = ops[2]
+	assert.Equal(t, CommandUpdate, op.Command)
+	assert.Equal(t, parentID, op.ID)
+	assert.Equal(t, map[string]interface{}{"last_edited_time": b.tx.now}, op.Args)
+
+	// nested to-do is created inside the list item
+	op = ops[5]
+	assert.Equal(t, testBlockID(103), op.ID)
+	args = op.Args.(map[string]interface{})
+	assert.Equal(t, testBlockID(102), args["parent_id"])
+	props := jsonRoundTrip(t, args["properties"])
+	exp := map[string]interface{}{
+		"title":   []interface{}{[]interface{}{"todo"}},
+		"checked": []interface{}{[]interface{}{"Yes"}},
+	}
+	assert.Equal(t, exp, props)
+	op = ops[6]
+	assert.Equal(t, map[string]string{"id": testBlockID(103)}, op.Args)
+
+	// code block is listed after the list item, not after its child
+	op = ops[7]
+	args = op.Args.(map[string]interface{})
+	props = jsonRoundTrip(t, args["properties"])
+	assert.Equal(t, []interface{}{[]interface{}{"Go"}}, props.(map[string]interface{})["language"])
+	op = ops[8]
+	assert.Equal(t, map[string]string{"id": testBlockID(104), "after": testBlockID(102)}, op.Args)
+
+	args = ops[9].Args.(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"page_icon": "💡"}, args["format"])
+
+	args = ops[11].Args.(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"display_source": "https://example.com/img.png"}, args["format"])
+}
+
+func TestBlockSpecValidate(t *testing.T) {
+	c := &Client{}
+	_, err := c.AppendBlocks(testBlockID(1), "", []BlockSpec{{Type: BlockCollectionView}})
+	assert.Error(t, err)
+	_, err = c.AppendBlocks(testBlockID(1), "", []BlockSpec{NewTextSpec("x", NewImageSpec(""))})
+	assert.Error(t, err)
+	_, err = c.AppendBlocks(testBlockID(1), "", nil)
+	assert.Error(t, err)
+}
+
+func TestCreatePage(t *testing.T) {
+	parentID := testBlockID(1)
+	var submitted submitTransactionRequest
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			switch {
+			case strings.Contains(uri, "/api/v3/syncRecordValues"):
+				rsp := map[string]interface{}{
+					"recordMap": map[string]interface{}{
+						"block": map[string]interface{}{
+							parentID: map[string]interface{}{
+								"role": "editor",
+								"value": map[string]interface{}{
+									"id":       parentID,
+									"type":     "page",
+									"alive":    true,
+									"space_id": testSpaceID,
+								},
+							},
+						},
+					},
+				}
+				return json.Marshal(rsp)
+			case strings.Contains(uri, "/api/v3/submitTransaction"):
+				err := json.Unmarshal(body, &submitted)
+				return []byte("{}"), err
+			}
+			return nil, fmt.Errorf("unexpected request to '%s'", uri)
+		},
+	}
+	pageID, err := c.CreatePage(ToNoDashID(parentID), "New page", []BlockSpec{
+		NewTextSpec("hello"),
+		NewDividerSpec(),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 36, len(pageID))
+
+	// page: set + listAfter + last edited time of parent,
+	// 2 children: set + listAfter
+	ops := submitted.Operations
+	assert.Equal(t, 7, len(ops))
+	assert.Equal(t, pageID, ops[0].ID)
+	args := ops[0].Args.(map[string]interface{})
+	assert.Equal(t, "page", args["type"])
+	assert.Equal(t, parentID, args["parent_id"])
+	assert.Equal(t, testSpaceID, args["space_id"])
+	assert.Equal(t, []interface{}{[]interface{}{"New page"}}, args["properties"].(map[string]interface{})["title"])
+	assert.Equal(t, parentID, ops[2].ID)
+	assert.Equal(t, CommandUpdate, ops[2].Command)
+	assert.Equal(t, pageID, ops[4].ID)
+	assert.Equal(t, pageID, ops[6].ID)
+}
+
+func TestAppendBlocksUpdatesPage(t *testing.T) {
+	pageID := testBlockID(1)
+	parentID := testBlockID(2)
+	blocks := map[string]interface{}{
+		pageID:   map[string]interface{}{"id": pageID, "type": "page", "alive": true, "space_id": testSpaceID},
+		parentID: map[string]interface{}{"id": parentID, "type": "toggle", "alive": true, "space_id": testSpaceID, "parent_id": pageID, "parent_table": "block"},
+	}
+	var submitted submitTransactionRequest
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			if strings.Contains(uri, "/api/v3/syncRecordValues") {
+				var req syncRecordRequest
+				assert.NoError(t, json.Unmarshal(body, &req))
+				id := req.Requests[0].Pointer.ID
+				rsp := map[string]interface{}{
+					"recordMap": map[string]interface{}{
+						"block": map[string]interface{}{
+							id: map[string]interface{}{"role": "editor", "value": blocks[id]},
+						},
+					},
+				}
+				return json.Marshal(rsp)
+			}
+			err := json.Unmarshal(body, &submitted)
+			return []byte("{}"), err
+		},
+	}
+	_, err := c.AppendBlocks(parentID, "", []BlockSpec{NewTextSpec("hello")})
+	assert.NoError(t, err)
+	// set + listAfter, last edited time of parent and of the page
+	ops := submitted.Operations
+	assert.Equal(t, 4, len(ops))
+	assert.Equal(t, parentID, ops[2].ID)
+	assert.Equal(t, pageID, ops[3].ID)
+	assert.Equal(t, CommandUpdate, ops[3].Command)
+}
+
+func TestAppendBlocksPartialCommit(t *testing.T) {
+	parentID := testBlockID(1)
+	parent := map[string]interface{}{"id": parentID, "type": "page", "alive": true, "space_id": testSpaceID}
+	nSubmits := 0
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			if strings.Contains(uri, "/api/v3/syncRecordValues") {
+				rsp := map[string]interface{}{
+					"recordMap": map[string]interface{}{
+						"block": map[string]interface{}{
+							parentID: map[string]interface{}{"role": "editor", "value": parent},
+						},
+					},
+				}
+				return json.Marshal(rsp)
+			}
+			nSubmits++
+			if nSubmits == 2 {
+				return nil, errors.New("failed")
+			}
+			return []byte("{}"), nil
+		},
+	}
+	// each block is too big to be sent with another one
+	text := strings.Repeat("x", DefaultMaxTransactionSize/2)
+	specs := []BlockSpec{NewTextSpec(text), NewTextSpec(text), NewTextSpec(text)}
+	ids, err := c.AppendBlocks(parentID, "", specs)
+	var commitErr *CommitError
+	assert.True(t, errors.As(err, &commitErr))
+	assert.Equal(t, 1, len(ids))
+	assert.Equal(t, 2, nSubmits)
+}
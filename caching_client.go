@@ -3,6 +3,7 @@ package notionapi
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/sha1"
 	"errors"
 	"fmt"
@@ -264,7 +265,7 @@ func (c *CachingClient) findCachedRequest(pageRequests []*RequestCacheEntry, met
 	return nil, false
 }
 
-func (c *CachingClient) doPostCacheOnly(uri string, body []byte, headers ...http.Header) ([]byte, error) {
+func (c *CachingClient) doPostCacheOnly(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
 	pageID := c.currPageID.NoDashID
 	pageRequests := c.pageIDToEntries[pageID]
 	r, ok := c.findCachedRequest(pageRequests, "POST", uri, string(body))
@@ -275,8 +276,8 @@ func (c *CachingClient) doPostCacheOnly(uri string, body []byte, headers ...http
 	return nil, fmt.Errorf("no cache response for '%s' of size %d", uri, len(body))
 }
 
-func (c *CachingClient) doPostNoCache(uri string, body []byte, headers ...http.Header) ([]byte, error) {
-	d, err := c.Client.doPostInternal(uri, body, headers...)
+func (c *CachingClient) doPostNoCache(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+	d, err := c.Client.doPostInternal(ctx, uri, body, headers...)
 	if err != nil {
 		return nil, err
 	}
@@ -306,8 +307,7 @@ func (c *CachingClient) getCachedPage(pageID *NotionID) *CachedPage {
 }
 
 // PreLoadCache will preload all pages in the cache.
-// It does so concurrently (which is not allowed in general)
-// so should be faster
+// It does so concurrently so should be faster
 func (c *CachingClient) PreLoadCache() {
 	if len(c.IdToCachedPage) > 0 {
 		return
@@ -334,11 +334,13 @@ func (c *CachingClient) PreLoadCache() {
 	var mu sync.Mutex
 	for _, id := range ids {
 		cachedPage := c.getCachedPage(id)
+		// each goroutine needs its own copy because httpPostOverride
+		// looks up cached responses for a given page
 		client := *c.Client
 		sem <- true // enter semaphore
 		wg.Add(1)
 		go func(client *Client, cp *CachedPage, nid *NotionID) {
-			client.httpPostOverride = func(uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			client.httpPostOverride = func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
 				pageID := nid.NoDashID
 				pageRequests := c.pageIDToEntries[pageID]
 				mu.Lock()
@@ -360,7 +362,13 @@ func (c *CachingClient) PreLoadCache() {
 	wg.Wait()
 }
 
+// DownloadPage downloads a page, using the cache according to c.Policy
 func (c *CachingClient) DownloadPage(pageID string) (*Page, error) {
+	return c.DownloadPageCtx(context.Background(), pageID)
+}
+
+// DownloadPageCtx is like DownloadPage but can be cancelled with ctx
+func (c *CachingClient) DownloadPageCtx(ctx context.Context, pageID string) (*Page, error) {
 	currPageID := NewNotionID(pageID)
 	if currPageID == nil {
 		return nil, fmt.Errorf("'%s' is not a valid notion id", pageID)
@@ -386,7 +394,7 @@ func (c *CachingClient) DownloadPage(pageID string) (*Page, error) {
 		timeStart := time.Now()
 		// when we're getting new versions, we have to disable all caching
 		c.Client.httpPostOverride = nil
-		blocks, err := c.Client.GetBlockRecords(ids)
+		blocks, err := c.Client.GetBlockRecordsCtx(ctx, ids)
 		if err != nil {
 			return
 		}
@@ -469,7 +477,7 @@ func (c *CachingClient) DownloadPage(pageID string) (*Page, error) {
 	if c.Policy == PolicyCacheOnly || c.Policy == PolicyDownloadNewer {
 		if cp.PageFromCache == nil {
 			c.Client.httpPostOverride = c.doPostCacheOnly
-			cp.PageFromCache, err = c.Client.DownloadPage(pageID)
+			cp.PageFromCache, err = c.Client.DownloadPageCtx(ctx, pageID)
 		}
 		if c.Policy == PolicyCacheOnly {
 			return cp.PageFromCache, err
@@ -487,7 +495,7 @@ func (c *CachingClient) DownloadPage(pageID string) (*Page, error) {
 
 	c.Client.httpPostOverride = c.doPostNoCache
 
-	cp.PageFromServer, err = c.Client.DownloadPage(pageID)
+	cp.PageFromServer, err = c.Client.DownloadPageCtx(ctx, pageID)
 	if err != nil {
 		if c.Policy == PolicyDownloadNewer && fromCache != nil {
 			return fromCache, nil
@@ -507,6 +515,12 @@ type DownloadInfo struct {
 }
 
 func (c *CachingClient) DownloadPagesRecursively(startPageID string, afterDownload func(*DownloadInfo) error) ([]*Page, error) {
+	return c.DownloadPagesRecursivelyCtx(context.Background(), startPageID, afterDownload)
+}
+
+// DownloadPagesRecursivelyCtx is like DownloadPagesRecursively but can be
+// cancelled with ctx
+func (c *CachingClient) DownloadPagesRecursivelyCtx(ctx context.Context, startPageID string, afterDownload func(*DownloadInfo) error) ([]*Page, error) {
 	toVisit := []*NotionID{NewNotionID(startPageID)}
 	downloaded := map[string]*Page{}
 	for len(toVisit) > 0 {
@@ -518,7 +532,7 @@ func (c *CachingClient) DownloadPagesRecursively(startPageID string, afterDownlo
 		nFromCache := c.RequestsFromCache
 		nFromServer := c.RequestsFromServer
 		timeStart := time.Now()
-		page, err := c.DownloadPage(pageID)
+		page, err := c.DownloadPageCtx(ctx, pageID)
 		if err != nil {
 			return nil, err
 		}
@@ -627,6 +641,11 @@ func guessExt(fileName string, contentType string) string {
 // DownloadFile downloads a file refered by block with a given blockID and a parent table
 // we cache the file
 func (c *CachingClient) DownloadFile(uri string, block *Block) (*DownloadFileResponse, error) {
+	return c.DownloadFileCtx(context.Background(), uri, block)
+}
+
+// DownloadFileCtx is like DownloadFile but can be cancelled with ctx
+func (c *CachingClient) DownloadFileCtx(ctx context.Context, uri string, block *Block) (*DownloadFileResponse, error) {
 
 	var data []byte
 	var err error
@@ -656,7 +675,7 @@ func (c *CachingClient) DownloadFile(uri string, block *Block) (*DownloadFileRes
 
 	timeStart := time.Now()
 	c.Client.httpPostOverride = nil
-	res, err := c.Client.DownloadFile(uri, block)
+	res, err := c.Client.DownloadFileCtx(ctx, uri, block)
 	if err != nil {
 		c.logf("CachingClient.DownloadFile: failed to download %s, error: %s", uri, err)
 		return nil, err
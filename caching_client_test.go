@@ -1,6 +1,8 @@
 package notionapi
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/kjk/common/require"
@@ -25,6 +27,16 @@ func testDownloadFromCache(t *testing.T, pageID string) *Page {
 	return p
 }
 
+func TestCachingClientDoPostNoCacheCtx(t *testing.T) {
+	cc, err := NewCachingClient(t.TempDir(), &Client{})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cc.doPostNoCache(ctx, notionHost+"/api/v3/syncRecordValues", []byte("{}"))
+	require.True(t, errors.Is(err, context.Canceled))
+	require.Equal(t, 0, cc.RequestsFromServer)
+}
+
 /*
 func convertToMdAndHTML(t *testing.T, page *Page) {
 	{
@@ -0,0 +1,264 @@
+package notionapi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// currency symbols for ColumnSchema.NumberFormat
+var numberFormatCurrency = map[string]string{
+	"dollar":          "$",
+	"canadian_dollar": "CA$",
+	"euro":            "€",
+	"pound":           "£",
+	"yen":             "¥",
+	"ruble":           "₽",
+	"rupee":           "₹",
+	"won":             "₩",
+	"yuan":            "CN¥",
+	"real":            "R$",
+	"lira":            "TL",
+	"franc":           "CHF",
+}
+
+// CellValue is a value of a column (property) of a collection row,
+// returned by TableRow.Get
+type CellValue struct {
+	// Row is the row this value belongs to
+	Row *TableRow
+	// Schema describes the column
+	Schema *ColumnSchema
+	// Spans is the raw value
+	Spans []*TextSpan
+}
+
+// Get returns the value of a column with a given name (or id)
+func (r *TableRow) Get(columnName string) (*CellValue, error) {
+	if r.Page == nil {
+		return nil, errors.New("row has no page")
+	}
+	if r.TableView == nil || r.TableView.Collection == nil {
+		return nil, errors.New("row doesn't have a collection")
+	}
+	colID, schema, err := r.TableView.Collection.findColumn(columnName)
+	if err != nil {
+		return nil, err
+	}
+	v := &CellValue{
+		Row:    r,
+		Schema: schema,
+	}
+	if raw, ok := r.Page.Properties[colID]; ok && raw != nil {
+		v.Spans, err = ParseTextSpans(raw)
+		if err != nil {
+			return nil, fmt.Errorf("column '%s': %w", schema.Name, err)
+		}
+	}
+	return v, nil
+}
+
+// Type returns type of the column e.g. ColumnTypeNumber
+func (v *CellValue) Type() string {
+	return v.Schema.Type
+}
+
+// IsEmpty returns true if the value is not set
+func (v *CellValue) IsEmpty() bool {
+	switch v.Schema.Type {
+	case ColumnTypeCreatedTime, ColumnTypeLastEditedTime, ColumnTypeCreatedBy, ColumnTypeLastEditedBy:
+		return false
+	}
+	return len(v.Spans) == 0
+}
+
+// Text returns value as plain text, without formatting
+func (v *CellValue) Text() string {
+	return TextSpansToString(v.Spans)
+}
+
+// String returns value formatted for display. Numbers are formatted
+// according to ColumnSchema.NumberFormat, dates with FormatDate
+func (v *CellValue) String() string {
+	switch v.Schema.Type {
+	case ColumnTypeNumber:
+		f, err := v.AsFloat()
+		if err != nil {
+			return v.Text()
+		}
+		return formatNumber(f, v.Schema.NumberFormat)
+	case ColumnTypeDate, ColumnTypeCreatedTime, ColumnTypeLastEditedTime:
+		if d := v.AsDate(); d != nil {
+			return FormatDate(d)
+		}
+		return ""
+	case ColumnTypeMultiSelect:
+		return strings.Join(v.AsStrings(), ", ")
+	}
+	return v.Text()
+}
+
+// AsBool returns value of ColumnTypeCheckbox column
+func (v *CellValue) AsBool() bool {
+	return v.Text() == "Yes"
+}
+
+// AsFloat returns value of ColumnTypeNumber column
+func (v *CellValue) AsFloat() (float64, error) {
+	s := strings.TrimSpace(v.Text())
+	if s == "" {
+		return 0, fmt.Errorf("column '%s' is empty", v.Schema.Name)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// AsStrings returns values of ColumnTypeMultiSelect column.
+// For other columns it's the text as a single element
+func (v *CellValue) AsStrings() []string {
+	s := v.Text()
+	if s == "" {
+		return nil
+	}
+	if v.Schema.Type != ColumnTypeMultiSelect {
+		return []string{s}
+	}
+	var res []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			res = append(res, part)
+		}
+	}
+	return res
+}
+
+func msToDate(ms int64) *Date {
+	if ms == 0 {
+		return nil
+	}
+	return timeToDate(time.UnixMilli(ms).UTC())
+}
+
+// AsDate returns value of ColumnTypeDate, ColumnTypeCreatedTime and
+// ColumnTypeLastEditedTime columns. Returns nil if there is no date
+func (v *CellValue) AsDate() *Date {
+	switch v.Schema.Type {
+	case ColumnTypeCreatedTime:
+		return msToDate(v.Row.Page.CreatedTime)
+	case ColumnTypeLastEditedTime:
+		return msToDate(v.Row.Page.LastEditedTime)
+	}
+	for _, ts := range v.Spans {
+		for _, attr := range ts.Attrs {
+			if AttrGetType(attr) == AttrDate {
+				return AttrGetDate(attr)
+			}
+		}
+	}
+	return nil
+}
+
+// idsWithAttr returns ids from attributes of type attrType (AttrUser or AttrPage)
+func (v *CellValue) idsWithAttr(attrType string) []string {
+	var res []string
+	for _, ts := range v.Spans {
+		for _, attr := range ts.Attrs {
+			if AttrGetType(attr) == attrType && len(attr) > 1 {
+				res = append(res, attr[1])
+			}
+		}
+	}
+	return res
+}
+
+// AsUserIDs returns ids of users in ColumnTypePerson, ColumnTypeCreatedBy
+// and ColumnTypeLastEditedBy columns
+func (v *CellValue) AsUserIDs() []string {
+	page := v.Row.Page
+	switch v.Schema.Type {
+	case ColumnTypeCreatedBy:
+		if page.CreatedByID != "" {
+			return []string{page.CreatedByID}
+		}
+		if page.CreatedBy != "" {
+			return []string{page.CreatedBy}
+		}
+		return nil
+	case ColumnTypeLastEditedBy:
+		if page.LastEditedByID != "" {
+			return []string{page.LastEditedByID}
+		}
+		if page.LastEditedBy != "" {
+			return []string{page.LastEditedBy}
+		}
+		return nil
+	}
+	return v.idsWithAttr(AttrUser)
+}
+
+// AsPageIDs returns ids of pages in ColumnTypeRelation column
+func (v *CellValue) AsPageIDs() []string {
+	return v.idsWithAttr(AttrPage)
+}
+
+// AsURL returns value of ColumnTypeURL column. For other columns (e.g.
+// ColumnTypeFile) it's the first link in the value
+func (v *CellValue) AsURL() string {
+	for _, ts := range v.Spans {
+		for _, attr := range ts.Attrs {
+			if AttrGetType(attr) == AttrLink {
+				return AttrGetLink(attr)
+			}
+		}
+	}
+	if v.Schema.Type == ColumnTypeURL {
+		return v.Text()
+	}
+	return ""
+}
+
+// addThousandsSeparator formats s (digits) as e.g. "1,234,567"
+func addThousandsSeparator(s string) string {
+	var sb strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// formatNumber formats f according to ColumnSchema.NumberFormat
+func formatNumber(f float64, numberFormat string) string {
+	withCommas := func(s string) string {
+		neg := strings.HasPrefix(s, "-")
+		s = strings.TrimPrefix(s, "-")
+		intPart, frac, hasFrac := strings.Cut(s, ".")
+		s = addThousandsSeparator(intPart)
+		if hasFrac {
+			s += "." + frac
+		}
+		if neg {
+			s = "-" + s
+		}
+		return s
+	}
+	if symbol, ok := numberFormatCurrency[numberFormat]; ok {
+		s := withCommas(strconv.FormatFloat(f, 'f', 2, 64))
+		if strings.HasPrefix(s, "-") {
+			return "-" + symbol + s[1:]
+		}
+		return symbol + s
+	}
+	switch numberFormat {
+	case "number_with_commas":
+		return withCommas(strconv.FormatFloat(f, 'f', -1, 64))
+	case "percent":
+		// rounding avoids e.g. 0.07 => "7.000000000000001%"
+		return strconv.FormatFloat(math.Round(f*1e8)/1e6, 'f', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
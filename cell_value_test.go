@@ -0,0 +1,83 @@
+package notionapi
+
+import (
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+func TestTableRowGet(t *testing.T) {
+	row := newTestTableRow()
+	row.TableView.Collection.Schema["file"] = &ColumnSchema{Name: "Files", Type: ColumnTypeFile}
+	row.TableView.Collection.Schema["crtd"] = &ColumnSchema{Name: "Created", Type: ColumnTypeCreatedTime}
+	row.TableView.Collection.Schema["crby"] = &ColumnSchema{Name: "Creator", Type: ColumnTypeCreatedBy}
+	row.Page.CreatedTime = 1614816000000 // 2021-03-04
+	row.Page.CreatedByID = "user-3"
+	row.Page.Properties = map[string]interface{}{
+		"title": spans("row ", "", "title", "b"),
+		"AbCd":  spans("Yes", ""),
+		"nmbr":  spans("1234.5", ""),
+		"tags":  spans("go,rust", ""),
+		"date": []interface{}{[]interface{}{TextSpanSpecial, []interface{}{[]interface{}{"d", map[string]interface{}{
+			"type":       "date",
+			"start_date": "2021-03-04",
+		}}}}},
+		"prsn": spans("‣", "u user-1", ",", "", "‣", "u user-2"),
+		"rltn": spans("‣", "p "+testBlockID(7)),
+		"url_": spans("https://example.com", ""),
+		"file": spans("a.pdf", "a https://example.com/a.pdf"),
+	}
+
+	get := func(name string) *CellValue {
+		v, err := row.Get(name)
+		assert.NoError(t, err)
+		return v
+	}
+
+	assert.Equal(t, "row title", get("Name").Text())
+	assert.True(t, get("Done").AsBool())
+	f, err := get("Price").AsFloat()
+	assert.NoError(t, err)
+	assert.Equal(t, 1234.5, f)
+	assert.Equal(t, "1234.5", get("Price").String())
+	assert.Equal(t, []string{"go", "rust"}, get("Tags").AsStrings())
+	assert.Equal(t, "go, rust", get("Tags").String())
+	assert.Equal(t, &Date{StartDate: "2021-03-04", Type: "date"}, get("Due").AsDate())
+	assert.Equal(t, "Mar 04, 2021", get("Due").String())
+	assert.Equal(t, []string{"user-1", "user-2"}, get("Owner").AsUserIDs())
+	assert.Equal(t, []string{testBlockID(7)}, get("Related").AsPageIDs())
+	assert.Equal(t, "https://example.com", get("Link").AsURL())
+	assert.Equal(t, "https://example.com/a.pdf", get("Files").AsURL())
+	assert.Equal(t, "Mar 04, 2021", get("Created").String())
+	assert.Equal(t, []string{"user-3"}, get("Creator").AsUserIDs())
+
+	empty := get("Status")
+	assert.True(t, empty.IsEmpty())
+	assert.Nil(t, empty.AsStrings())
+	assert.Nil(t, get("Formula").AsDate())
+	_, err = get("Formula").AsFloat()
+	assert.Error(t, err)
+
+	_, err = row.Get("Missing")
+	assert.Error(t, err)
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		f      float64
+		format string
+		exp    string
+	}{
+		{1234.5, "", "1234.5"},
+		{1234.5, "number", "1234.5"},
+		{1234567.25, "number_with_commas", "1,234,567.25"},
+		{-1234, "number_with_commas", "-1,234"},
+		{0.07, "percent", "7%"},
+		{1234.5, "dollar", "$1,234.50"},
+		{-5, "euro", "-€5.00"},
+		{100, "yen", "¥100.00"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.exp, formatNumber(tc.f, tc.format))
+	}
+}
@@ -2,12 +2,14 @@ package notionapi
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,7 +20,9 @@ const (
 	acceptLang = "en-US,en;q=0.9"
 )
 
-// Client is client for invoking Notion API
+// Client is client for invoking Notion API. Create it with NewClient
+// (zero value also works, see RateLimiter).
+// It's safe for concurrent use by multiple goroutines
 type Client struct {
 	// AuthToken allows accessing non-public pages.
 	AuthToken string
@@ -29,15 +33,51 @@ type Client struct {
 	Logger io.Writer
 	// DebugLog enables debug logging
 	DebugLog bool
-	// MinRequestDelay is for controlling rate limiting. it's 333 ms by default
+	// MinRequestDelay is for controlling rate limiting. it's 360 ms by default
 	// because https://developers.notion.com/reference/errors#rate-limits
 	// says rate limit is, on average, 3 requests per second
 	MinRequestDelay time.Duration
-	// simplest rate limiting: track last request time and wait at least
-	// MinRequestDelay between requests
-	lastRequestTime time.Time
+	// RateLimitBurst is how many requests can be sent without waiting
+	// MinRequestDelay between them. 1 by default
+	RateLimitBurst int
+	// RateLimiter allows over-riding rate limiting. If not set, we use
+	// a TokenBucketLimiter based on MinRequestDelay and RateLimitBurst,
+	// which is shared by copies of a Client created with NewClient.
+	// Clients not created with NewClient share it with all such Clients
+	// with the same MinRequestDelay and RateLimitBurst.
+	// To share rate limit between multiple Clients, set the same RateLimiter
+	RateLimiter RateLimiter
+	// RetryPolicy controls retrying of failed requests.
+	// If not set, we use DefaultRetryPolicy()
+	RetryPolicy *RetryPolicy
+
+	// created in NewClient, so that copies of the Client share it
+	defaultRateLimiter *lazyRateLimiter
+
+	httpPostOverride func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error)
+}
+
+// NewClient returns a new Client. Copies of the Client share the rate limiter
+func NewClient() *Client {
+	return &Client{
+		defaultRateLimiter: &lazyRateLimiter{},
+	}
+}
 
-	httpPostOverride func(uri string, body []byte, headers ...http.Header) ([]byte, error)
+// sleepCtx sleeps for d or until ctx is done, whichever comes first.
+// Returns ctx.Err() if ctx was cancelled.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
 }
 
 // vlogf is for verbose logging
@@ -59,52 +99,77 @@ func (c *Client) logf(format string, args ...interface{}) {
 	fmt.Fprintf(c.Logger, format, args...)
 }
 
+var (
+	defaultHTTPClient     *http.Client
+	defaultHTTPClientOnce sync.Once
+)
+
 func (c *Client) getHTTPClient() *http.Client {
 	if c.HTTPClient != nil {
 		return c.HTTPClient
 	}
-	tr := &http.Transport{
-		// Key point: Disable HTTP/2 by setting TLSNextProto to empty.
-		// This prevents the client from negotiating "h2" (HTTP/2) during the TLS handshake.
-		TLSNextProto: make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
+	// shared by all clients so that connections can be re-used
+	defaultHTTPClientOnce.Do(func() {
+		tr := &http.Transport{
+			// Key point: Disable HTTP/2 by setting TLSNextProto to empty.
+			// This prevents the client from negotiating "h2" (HTTP/2) during the TLS handshake.
+			TLSNextProto: make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
+		}
+		defaultHTTPClient = &http.Client{
+			Transport: tr,
+			Timeout:   time.Second * 30,
+		}
+	})
+	return defaultHTTPClient
+}
+
+func (c *Client) doPost(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	return &http.Client{
-		Transport: tr,
-		Timeout:   time.Second * 30,
+	if c.httpPostOverride != nil {
+		return c.httpPostOverride(ctx, uri, body, headers...)
 	}
+	return c.doPostInternal(ctx, uri, body, headers...)
 }
 
-func (c *Client) rateLimitRequest() {
-	if !c.lastRequestTime.IsZero() {
-		minDelay := c.MinRequestDelay
-		if minDelay == 0 {
-			minDelay = time.Millisecond * 360
+func (c *Client) doPostInternal(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+	policy := c.getRetryPolicy()
+	for attempt := 1; ; attempt++ {
+		d, err := c.doPostOnce(ctx, uri, body, headers...)
+		if err == nil {
+			return d, nil
+		}
+		if !policy.shouldRetry(ctx, attempt, err) {
+			return nil, err
+		}
+		info := &RetryInfo{
+			URI:     uri,
+			Attempt: attempt,
+			Delay:   policy.delay(attempt, err),
+			Err:     err,
 		}
-		since := time.Since(c.lastRequestTime)
-		if minDelay > since {
-			time.Sleep(minDelay - since)
+		if apiErr, ok := AsAPIError(err); ok {
+			info.StatusCode = apiErr.StatusCode
+		}
+		c.logf("retrying '%s' in %s because of: %s\n", uri, info.Delay, err)
+		if policy.OnRetry != nil {
+			policy.OnRetry(info)
+		}
+		if err := sleepCtx(ctx, info.Delay); err != nil {
+			return nil, err
 		}
 	}
-	c.lastRequestTime = time.Now()
 }
 
-func (c *Client) doPost(uri string, body []byte, headers ...http.Header) ([]byte, error) {
-	if c.httpPostOverride != nil {
-		return c.httpPostOverride(uri, body, headers...)
+// doPostOnce does a single, rate-limited, POST request
+func (c *Client) doPostOnce(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+	if err := c.getRateLimiter().Wait(ctx); err != nil {
+		return nil, err
 	}
-	return c.doPostInternal(uri, body, headers...)
-}
-
-func (c *Client) doPostInternal(uri string, body []byte, headers ...http.Header) ([]byte, error) {
-	c.rateLimitRequest()
 
-	// try to back-off exponentially
-	// note: backing off doesn't seem to work i.e. I get 429 from subsequent requests as well
-	nRepeats := 0
-	timeouts := []time.Duration{time.Second * 3, time.Second * 5, time.Second * 10}
-repeatRequest:
 	br := bytes.NewBuffer(body)
-	req, err := http.NewRequest("POST", uri, br)
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, br)
 	if err != nil {
 		return nil, err
 	}
@@ -119,32 +184,19 @@ repeatRequest:
 			req.Header.Set(k, header.Get(k))
 		}
 	}
-	var rsp *http.Response
 
 	httpClient := c.getHTTPClient()
-	rsp, err = httpClient.Do(req)
-
+	rsp, err := httpClient.Do(req)
 	if err != nil {
 		c.logf("httpClient.Do() failed with %s\n", err)
 		return nil, err
 	}
-
-	if rsp.StatusCode == http.StatusTooManyRequests {
-		if nRepeats < 3 {
-			closeNoError(rsp.Body)
-			c.logf("retrying '%s' because httpClient.Do() returned %d (%s)\n", uri, rsp.StatusCode, rsp.Status)
-			time.Sleep(timeouts[nRepeats])
-			nRepeats++
-			goto repeatRequest
-		}
-	}
-
 	defer closeNoError(rsp.Body)
 
 	if rsp.StatusCode != 200 {
 		d, _ := io.ReadAll(rsp.Body)
 		c.logf("Error: status code %s\nBody:\n%s\n", rsp.Status, PrettyPrintJS(d))
-		return nil, fmt.Errorf("http.Post('%s') returned non-200 status code of %d", uri, rsp.StatusCode)
+		return nil, newAPIError("POST", uri, rsp, d)
 	}
 	d, err := io.ReadAll(rsp.Body)
 	if err != nil {
@@ -154,7 +206,7 @@ repeatRequest:
 	return d, nil
 }
 
-func (c *Client) doNotionAPI(apiURL string, requestData any, result any, rawJSON *map[string]any, headers ...http.Header) error {
+func (c *Client) doNotionAPI(ctx context.Context, apiURL string, requestData any, result any, rawJSON *map[string]any, headers ...http.Header) error {
 	var body []byte
 	var err error
 	if requestData != nil {
@@ -169,7 +221,7 @@ func (c *Client) doNotionAPI(apiURL string, requestData any, result any, rawJSON
 		logJSON(c, body)
 	}
 
-	d, err := c.doPost(uri, body, headers...)
+	d, err := c.doPost(ctx, uri, body, headers...)
 	if err != nil {
 		return err
 	}
@@ -232,8 +284,10 @@ func (p *Page) findInlinePageReferences(block *Block) []string {
 	return res
 }
 
-// find referenced blocks that we don't have yet
-func (p *Page) findMissingBlocks() []string {
+// find referenced blocks that we don't have yet.
+// If skipPageReferences is true, we don't look for pages
+// referenced inline (AttrPage) in text of blocks
+func (p *Page) findMissingBlocks(skipPageReferences bool) []string {
 	missing := map[string]struct{}{}
 	for _, block := range p.idToBlock {
 		if !block.Alive {
@@ -250,6 +304,9 @@ func (p *Page) findMissingBlocks() []string {
 				missing[id] = struct{}{}
 			}
 		}
+		if skipPageReferences {
+			continue
+		}
 		referencedPages := p.findInlinePageReferences(block)
 		for _, id := range referencedPages {
 			if _, ok := p.idToBlock[id]; !ok {
@@ -269,8 +326,266 @@ func (p *Page) findMissingBlocks() []string {
 	return res
 }
 
+// DownloadOptions allows customizing DownloadPageWithOptions
+type DownloadOptions struct {
+	// Concurrency is the maximum number of requests issued in parallel
+	// when fetching missing blocks and querying collection views.
+	// 0 or 1 means requests are sent one at a time.
+	// All requests still go through Client's RateLimiter
+	Concurrency int
+	// SkipCollections disables querying rows of collection views.
+	// Page.TableViews will be empty
+	SkipCollections bool
+	// RowLimit is the maximum number of rows fetched for each collection view.
+	// 0 means the default of 50. RowLimitAll fetches all rows
+	RowLimit int
+	// SkipPageReferences disables downloading pages referenced
+	// inline in text (AttrPage)
+	SkipPageReferences bool
+	// MaxMissingIterations limits how many rounds of fetching missing
+	// (referenced but not yet downloaded) blocks we do. Blocks that are
+	// still missing after that are not part of the page.
+	// 0 means no limit
+	MaxMissingIterations int
+}
+
+const (
+	// RowLimitAll is DownloadOptions.RowLimit value that
+	// fetches all rows of collection views
+	RowLimitAll = -1
+
+	// number of rows in the first request when fetching all rows.
+	// We double it until we get all rows
+	rowLimitAllInitial = 1000
+)
+
+// runConcurrently calls fn(ctx, i) for i in [0, n) using up to
+// concurrency goroutines. It returns the first error and cancels
+// ctx passed to the remaining calls
+func runConcurrently(ctx context.Context, n int, concurrency int, fn func(ctx context.Context, i int) error) error {
+	if concurrency <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			if err := fn(ctx, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	parentCtx := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sem := make(chan bool, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		sem <- true // enter semaphore
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem // leave semaphore
+				wg.Done()
+			}()
+			if err := fn(ctx, i); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return parentCtx.Err()
+}
+
+// collectionViewQuery is a query for rows of a single collection view on a page
+type collectionViewQuery struct {
+	block            *Block
+	collection       *Collection
+	collectionID     string
+	collectionView   *CollectionView
+	collectionViewID string
+
+	res *QueryCollectionResponse
+}
+
+// queryCollectionWithLimit queries up to limit rows of a collection view.
+// If limit is RowLimitAll and server says there are more rows, we re-query
+// with the total number of rows reported by the server or, if it's not
+// reported, with increasing limit
+func (c *Client) queryCollectionWithLimit(ctx context.Context, req QueryCollectionRequest, query *Query, limit int) (*QueryCollectionResponse, error) {
+	params := map[string]string{"src": "initial_load"}
+	if limit != RowLimitAll {
+		if limit > 0 {
+			req.Loader = MakeLoaderReducer(query, limit)
+		}
+		return c.QueryCollectionCtx(ctx, req, query, params)
+	}
+
+	limit = rowLimitAllInitial
+	prevCount := -1
+	for {
+		req.Loader = MakeLoaderReducer(query, limit)
+		res, err := c.QueryCollectionCtx(ctx, req, query, params)
+		if err != nil {
+			return nil, err
+		}
+		rr := res.Result.ReducerResults
+		if rr == nil || rr.CollectionGroupResults == nil || !rr.CollectionGroupResults.HasMore {
+			return res, nil
+		}
+		// server might cap the number of returned rows (e.g. to 1000 when
+		// not logged in). Stop if asking for more didn't give us more
+		n := len(rr.CollectionGroupResults.BlockIds)
+		if n <= prevCount {
+			return res, nil
+		}
+		prevCount = n
+		if total := rr.CollectionGroupResults.Total; total > limit {
+			limit = total
+		} else {
+			limit *= 2
+		}
+	}
+}
+
+// loadTableViews queries rows of all collection views on the page
+// and builds TableViews from them
+func (c *Client) loadTableViews(ctx context.Context, p *Page, opts *DownloadOptions) error {
+	var queries []*collectionViewQuery
+	blockIDs := getBlockIDsSorted(p.idToBlock)
+	for _, id := range blockIDs {
+		block := p.idToBlock[id]
+		if block.Type != BlockCollectionView && block.Type != BlockCollectionViewPage {
+			continue
+		}
+		if len(block.ViewIDs) == 0 {
+			return fmt.Errorf("collection_view has no ViewIDs")
+		}
+
+		// TODO: should fish out the user based on block.CreatedBy
+		// TODO: notion changed the api and User is no long returned in loadPageChunk
+		// need to use syncRecordValues
+		if false && len(p.UserRecords) == 0 {
+			return fmt.Errorf("no users when trying to resolve collection_view")
+		}
+
+		collectionID := block.FixCollectionID()
+		collectionIDs := block.CollectionIDs()
+		for i, collectionViewID := range block.ViewIDs {
+			collectionView, ok := p.idToCollectionView[collectionViewID]
+			if !ok {
+				return fmt.Errorf("didn't find collection_view with id '%s'", collectionViewID)
+			}
+			collection, ok := p.idToCollection[collectionID]
+			if !ok {
+				//return fmt.Errorf("Didn't find collection with id '%s'", collectionID)
+				continue
+			}
+
+			if len(collectionIDs) > i { // support for multiple collections on one page
+				if c, ok := p.idToCollection[collectionIDs[i]]; ok {
+					collection = c
+					collectionID = collectionIDs[i]
+				}
+			}
+			q := &collectionViewQuery{
+				block:            block,
+				collection:       collection,
+				collectionID:     collectionID,
+				collectionView:   collectionView,
+				collectionViewID: collectionViewID,
+			}
+			queries = append(queries, q)
+		}
+	}
+
+	err := runConcurrently(ctx, len(queries), opts.Concurrency, func(ctx context.Context, i int) error {
+		q := queries[i]
+		spaceID := q.block.SpaceID
+		req := QueryCollectionRequest{}
+		req.Collection.ID = q.collectionID
+		req.Collection.SpaceID = spaceID
+		req.CollectionView.ID = q.collectionViewID
+		req.CollectionView.SpaceID = spaceID
+		res, err := c.queryCollectionWithLimit(ctx, req, q.collectionView.Query, opts.RowLimit)
+		q.res = res
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// build table views in a fixed order, regardless of the order
+	// in which queries finished
+	var prevBlock *Block
+	spaceShortId := ""
+	for _, q := range queries {
+		block := q.block
+		if block != prevBlock {
+			spaceShortId = ""
+			prevBlock = block
+		}
+		res := q.res
+		tableView := &TableView{
+			Page:           p,
+			CollectionView: q.collectionView,
+			Collection:     q.collection,
+			SpaceId:        block.SpaceID,
+			SizeHint:       res.Result.SizeHint,
+			SpaceShortId:   spaceShortId,
+		}
+		if err := c.buildTableView(tableView, res); err != nil {
+			return err
+		}
+		block.TableViews = append(block.TableViews, tableView)
+		p.TableViews = append(p.TableViews, tableView)
+
+		if tableView.SizeHint > 1000 && c.AuthToken == "" && spaceShortId == "" {
+			// need space short id to get more than 1000 items
+			if rsp, err := c.QuerySpaceShortIdCtx(ctx, p.ID, q.collectionViewID); err == nil {
+				spaceShortId = rsp.SpaceShortId
+				tableView.SpaceShortId = spaceShortId
+			}
+		}
+
+		if opts.RowLimit == RowLimitAll {
+			if err := c.loadAllTableRows(ctx, tableView); err != nil {
+				return err
+			}
+			spaceShortId = tableView.SpaceShortId
+		}
+	}
+	return nil
+}
+
 // DownloadPage returns Notion page data given its id
 func (c *Client) DownloadPage(pageID string) (*Page, error) {
+	return c.DownloadPageWithOptionsCtx(context.Background(), pageID, nil)
+}
+
+// DownloadPageCtx is like DownloadPage but can be cancelled with ctx
+func (c *Client) DownloadPageCtx(ctx context.Context, pageID string) (*Page, error) {
+	return c.DownloadPageWithOptionsCtx(ctx, pageID, nil)
+}
+
+// DownloadPageWithOptions is like DownloadPage but allows customizing
+// how the page is downloaded. opts can be nil
+func (c *Client) DownloadPageWithOptions(pageID string, opts *DownloadOptions) (*Page, error) {
+	return c.DownloadPageWithOptionsCtx(context.Background(), pageID, opts)
+}
+
+// DownloadPageWithOptionsCtx is like DownloadPageWithOptions but can be cancelled with ctx
+func (c *Client) DownloadPageWithOptionsCtx(ctx context.Context, pageID string, opts *DownloadOptions) (*Page, error) {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
 	id := ToDashID(pageID)
 	if !IsValidDashID(id) {
 		return nil, fmt.Errorf("%s is not a valid Notion page id", id)
@@ -295,7 +610,7 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 	var root *Block
 	// get page's root block and then recursively download referenced blocks
 	{
-		blocks, err := c.GetBlockRecords([]string{pageID})
+		blocks, err := c.GetBlockRecordsCtx(ctx, []string{pageID})
 		if err != nil {
 			return nil, err
 		}
@@ -311,7 +626,7 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 	chunkNo := 0
 	var cur *cursor
 	for {
-		rsp, err := c.LoadCachedPageChunk(pageID, chunkNo, cur)
+		rsp, err := c.LoadCachedPageChunkCtx(ctx, pageID, chunkNo, cur)
 		chunkNo++
 		if err != nil {
 			return nil, err
@@ -360,16 +675,21 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 	// get blocks that are not already loaded
 	missingIter := 1
 	for {
-		missing := p.findMissingBlocks()
+		missing := p.findMissingBlocks(opts.SkipPageReferences)
 		if len(missing) == 0 {
 			break
 		}
+		if opts.MaxMissingIterations > 0 && missingIter > opts.MaxMissingIterations {
+			c.vlogf("DownloadPage: stopping with %d missing blocks after %d iterations\n", len(missing), opts.MaxMissingIterations)
+			break
+		}
 		c.vlogf("DownloadPage: %d missing blocks in iteration %d\n", len(missing), missingIter)
 		missingIter++
 
 		// the API worked even with 6k items, but I'll split it into many
 		// smaller requests anyway
 		maxToGet := 128 * 10
+		var batches [][]string
 		for len(missing) > 0 {
 			toGet := missing
 			if len(toGet) > maxToGet {
@@ -378,11 +698,23 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 			} else {
 				missing = nil
 			}
+			batches = append(batches, toGet)
+		}
 
-			blocks, err := c.GetBlockRecords(toGet)
-			if err != nil {
-				return nil, err
-			}
+		batchesBlocks := make([][]*Block, len(batches))
+		err := runConcurrently(ctx, len(batches), opts.Concurrency, func(ctx context.Context, i int) error {
+			blocks, err := c.GetBlockRecordsCtx(ctx, batches[i])
+			batchesBlocks[i] = blocks
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// process results in the order of requests so that the result
+		// doesn't depend on the order in which requests finished
+		for i, toGet := range batches {
+			blocks := batchesBlocks[i]
 			for n, block := range blocks {
 				// This can happen e.g. in 157765353f2c4705bd45474e5ba8b46c
 				// Server returns { "role": "none" },
@@ -436,76 +768,9 @@ func (c *Client) DownloadPage(pageID string) (*Page, error) {
 			}
 	*/
 
-	blockIDs := getBlockIDsSorted(p.idToBlock)
-	for _, id := range blockIDs {
-		block := p.idToBlock[id]
-		if block.Type != BlockCollectionView && block.Type != BlockCollectionViewPage {
-			continue
-		}
-		if len(block.ViewIDs) == 0 {
-			return nil, fmt.Errorf("collection_view has no ViewIDs")
-		}
-
-		// TODO: should fish out the user based on block.CreatedBy
-		// TODO: notion changed the api and User is no long returned in loadPageChunk
-		// need to use syncRecordValues
-		if false && len(p.UserRecords) == 0 {
-			return nil, fmt.Errorf("no users when trying to resolve collection_view")
-		}
-
-		collectionID := block.FixCollectionID()
-		collectionIDs := block.CollectionIDs()
-		spaceShortId := ""
-		for i, collectionViewID := range block.ViewIDs {
-			collectionView, ok := p.idToCollectionView[collectionViewID]
-			if !ok {
-				return nil, fmt.Errorf("didn't find collection_view with id '%s'", collectionViewID)
-			}
-			collection, ok := p.idToCollection[collectionID]
-			if !ok {
-				//return nil, fmt.Errorf("Didn't find collection with id '%s'", collectionID)
-				continue
-			}
-
-			if len(collectionIDs) > i { // support for multiple collections on one page
-				if c, ok := p.idToCollection[collectionIDs[i]]; ok {
-					collection = c
-					collectionID = collectionIDs[i]
-				}
-			}
-
-			spaceID := block.SpaceID
-			req := QueryCollectionRequest{}
-			req.Collection.ID = collectionID
-			req.Collection.SpaceID = spaceID
-			req.CollectionView.ID = collectionViewID
-			req.CollectionView.SpaceID = spaceID
-			res, err := c.QueryCollection(req, collectionView.Query, map[string]string{"src": "initial_load"})
-			if err != nil {
-				return nil, err
-			}
-
-			tableView := &TableView{
-				Page:           p,
-				CollectionView: collectionView,
-				Collection:     collection,
-				SpaceId:        spaceID,
-				SizeHint:       res.Result.SizeHint,
-				SpaceShortId:   spaceShortId,
-			}
-			if err := c.buildTableView(tableView, res); err != nil {
-				return nil, err
-			}
-			block.TableViews = append(block.TableViews, tableView)
-			p.TableViews = append(p.TableViews, tableView)
-
-			if tableView.SizeHint > 1000 && c.AuthToken == "" && spaceShortId == "" {
-				// need space short id to get more than 1000 items
-				if rsp, err := c.QuerySpaceShortId(p.ID, collectionViewID); err == nil {
-					spaceShortId = rsp.SpaceShortId
-					tableView.SpaceShortId = spaceShortId
-				}
-			}
+	if !opts.SkipCollections {
+		if err := c.loadTableViews(ctx, p, opts); err != nil {
+			return nil, err
 		}
 	}
 
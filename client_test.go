@@ -1,7 +1,13 @@
 package notionapi
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/kjk/common/assert"
 )
@@ -39,3 +45,123 @@ func TestExtractNoDashIDFromNotionURL(t *testing.T) {
 		assert.Equal(t, exp, got)
 	}
 }
+
+func TestDownloadPageCtxCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &Client{}
+	_, err := c.DownloadPageCtx(ctx, "ea07db1b9bff415ab180b0525f3898f6")
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestSleepCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	timeStart := time.Now()
+	err := sleepCtx(ctx, time.Minute)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.True(t, time.Since(timeStart) < time.Second)
+
+	err = sleepCtx(context.Background(), time.Millisecond)
+	assert.NoError(t, err)
+}
+
+func TestRunConcurrently(t *testing.T) {
+	for _, concurrency := range []int{0, 1, 4} {
+		res := make([]int, 10)
+		err := runConcurrently(context.Background(), len(res), concurrency, func(ctx context.Context, i int) error {
+			res[i] = i * 2
+			return nil
+		})
+		assert.NoError(t, err)
+		for i, v := range res {
+			assert.Equal(t, i*2, v)
+		}
+	}
+
+	errFailed := errors.New("failed")
+	err := runConcurrently(context.Background(), 10, 4, func(ctx context.Context, i int) error {
+		if i == 3 {
+			return errFailed
+		}
+		return nil
+	})
+	assert.True(t, errors.Is(err, errFailed))
+}
+
+// fakeQueryCollection simulates /api/v3/queryCollection for a collection
+// with nRows rows where server returns at most maxRows rows. If total is
+// not 0, it's reported as the total number of rows
+func fakeQueryCollection(nRows int, maxRows int, total int, limits *[]int) func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+	return func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+		var req struct {
+			Loader struct {
+				Reducers struct {
+					Results struct {
+						Limit int `json:"limit"`
+					} `json:"collection_group_results"`
+				} `json:"reducers"`
+			} `json:"loader"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, err
+		}
+		limit := req.Loader.Reducers.Results.Limit
+		*limits = append(*limits, limit)
+		n := limit
+		if n > nRows {
+			n = nRows
+		}
+		if n > maxRows {
+			n = maxRows
+		}
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("%d", i)
+		}
+		rsp := map[string]interface{}{
+			"recordMap": map[string]interface{}{},
+			"result": map[string]interface{}{
+				"reducerResults": map[string]interface{}{
+					"collection_group_results": map[string]interface{}{
+						"blockIds": ids,
+						"total":    total,
+						"hasMore":  n < nRows,
+					},
+				},
+			},
+		}
+		return json.Marshal(rsp)
+	}
+}
+
+func TestQueryCollectionWithLimit(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		limit    int
+		nRows    int
+		maxRows  int
+		total    int
+		expLimit []int
+		expRows  int
+	}{
+		{0, 100, 5000, 100, []int{50}, 50},
+		{10, 100, 5000, 100, []int{10}, 10},
+		{RowLimitAll, 100, 5000, 100, []int{1000}, 100},
+		{RowLimitAll, 2500, 5000, 2500, []int{1000, 2500}, 2500},
+		// total is not reported
+		{RowLimitAll, 2500, 5000, 0, []int{1000, 2000, 4000}, 2500},
+		// server never returns more than 1000 rows
+		{RowLimitAll, 2500, 1000, 2500, []int{1000, 2500}, 1000},
+	}
+	for _, tc := range tests {
+		var limits []int
+		c := &Client{
+			httpPostOverride: fakeQueryCollection(tc.nRows, tc.maxRows, tc.total, &limits),
+		}
+		res, err := c.queryCollectionWithLimit(ctx, QueryCollectionRequest{}, nil, tc.limit)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expLimit, limits)
+		assert.Equal(t, tc.expRows, len(res.Result.ReducerResults.CollectionGroupResults.BlockIds))
+	}
+}
@@ -1,6 +1,7 @@
 package notionapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -12,6 +13,14 @@ const (
 	CollectionViewTypeTable = "table"
 	// CollectionViewTypeTable is a lists block
 	CollectionViewTypeList = "list"
+	// CollectionViewTypeBoard is a board (kanban) view, grouped by a column
+	CollectionViewTypeBoard = "board"
+	// CollectionViewTypeCalendar is a calendar view of a date column
+	CollectionViewTypeCalendar = "calendar"
+	// CollectionViewTypeGallery is a gallery of cards
+	CollectionViewTypeGallery = "gallery"
+	// CollectionViewTypeTimeline is a timeline view of a date column
+	CollectionViewTypeTimeline = "timeline"
 )
 
 // CollectionColumnOption describes options for ColumnTypeMultiSelect
@@ -22,20 +31,43 @@ type CollectionColumnOption struct {
 	Value string `json:"value"`
 }
 
+// FormulaArg is a node of a formula expression tree
 type FormulaArg struct {
 	Name       *string `json:"name,omitempty"`
 	ResultType string  `json:"result_type"`
-	Type       string  `json:"type"`
-	Value      *string `json:"value,omitempty"`
-	ValueType  *string `json:"value_type,omitempty"`
+	// "constant", "symbol", "property", "operator", "function", "conditional"
+	Type      string  `json:"type"`
+	Value     *string `json:"value,omitempty"`
+	ValueType *string `json:"value_type,omitempty"`
+
+	// for Type == "property", id of the column
+	ID string `json:"id,omitempty"`
+
+	// for Type == "operator" and "function"
+	Operator string       `json:"operator,omitempty"`
+	Args     []FormulaArg `json:"args,omitempty"`
+
+	// for Type == "conditional"
+	Condition *FormulaArg `json:"condition,omitempty"`
+	True      *FormulaArg `json:"true,omitempty"`
+	False     *FormulaArg `json:"false,omitempty"`
 }
 
+// ColumnFormula is the root of a formula expression tree of
+// ColumnTypeFormula column
 type ColumnFormula struct {
 	Args       []FormulaArg `json:"args"`
 	Name       string       `json:"name"`
 	Operator   string       `json:"operator"`
 	ResultType string       `json:"result_type"`
 	Type       string       `json:"type"`
+
+	ID        string      `json:"id,omitempty"`
+	Value     *string     `json:"value,omitempty"`
+	ValueType *string     `json:"value_type,omitempty"`
+	Condition *FormulaArg `json:"condition,omitempty"`
+	True      *FormulaArg `json:"true,omitempty"`
+	False     *FormulaArg `json:"false,omitempty"`
 }
 
 // ColumnSchema describes a info of a collection column
@@ -142,11 +174,20 @@ type QueryAggregation struct {
 	Aggregator string `json:"aggregator"`
 }
 
+// Query describes filter and sort of a collection view (query2).
+// Use BuildQuery to create it from Filter and SortSpec
 type Query struct {
 	Sort         []QuerySort            `json:"sort"`
 	Aggregate    []QueryAggregate       `json:"aggregate"`
 	Aggregations []QueryAggregation     `json:"aggregations"`
 	Filter       map[string]interface{} `json:"filter"`
+
+	// id of a column rows are grouped by in CollectionViewTypeBoard
+	GroupBy string `json:"group_by,omitempty"`
+	// id of a date column of CollectionViewTypeCalendar
+	CalendarBy string `json:"calendar_by,omitempty"`
+	// id of a date column of CollectionViewTypeTimeline
+	TimelineBy string `json:"timeline_by,omitempty"`
 }
 
 // FormatTable describes format for BlockTable
@@ -181,7 +222,8 @@ type TableRow struct {
 	// data for row is stored as properties of a page
 	Page *Block
 
-	// values extracted from Page for each column
+	// values extracted from Page for each column, in
+	// TableView.Columns (ColumnInfo.Index) order
 	Columns [][]*TextSpan
 }
 
@@ -228,6 +270,11 @@ type TableView struct {
 	SizeHint     int
 	SpaceId      string
 	SpaceShortId string
+
+	// pages and collections referred to by relation columns, by dash id.
+	// Set by Client.ResolveRelations
+	relatedPages       map[string]*Block
+	relatedCollections map[string]*Collection
 }
 
 func (t *TableView) RowCount() int {
@@ -238,12 +285,62 @@ func (t *TableView) ColumnCount() int {
 	return len(t.Columns)
 }
 
+// CellContent returns raw content of a cell. Use TableRow.Get
+// to get a typed value
 func (t *TableView) CellContent(row, col int) []*TextSpan {
 	return t.Rows[row].Columns[col]
 }
 
-// TODO: some tables miss title column in TableProperties
-// maybe synthesize it if doesn't exist as a first column
+// buildColumns sets tv.Columns from visible properties of the collection view.
+// Some tables miss title column in TableProperties, so we synthesize it
+// as the first column
+func (tv *TableView) buildColumns() {
+	tv.Columns = nil
+	collection := tv.Collection
+	if collection.Schema == nil {
+		return
+	}
+	var props []*TableProperty
+	if tv.CollectionView.Format != nil {
+		props = tv.CollectionView.Format.TableProperties
+	}
+	hasTitle := false
+	for _, prop := range props {
+		if prop.Property == "title" {
+			hasTitle = true
+		}
+	}
+	// title column can't be hidden
+	if !hasTitle && collection.Schema["title"] != nil {
+		titleProp := &TableProperty{
+			Property: "title",
+			Visible:  true,
+		}
+		props = append([]*TableProperty{titleProp}, props...)
+	}
+	for _, prop := range props {
+		if !prop.Visible && prop.Property != "title" {
+			continue
+		}
+		ci := &ColumnInfo{
+			TableView: tv,
+
+			Index:    len(tv.Columns),
+			Property: prop,
+			Schema:   collection.Schema[prop.Property],
+		}
+		tv.Columns = append(tv.Columns, ci)
+	}
+}
+
+// setRowColumns sets tr.Columns to values of tv.Columns
+func (tv *TableView) setRowColumns(tr *TableRow) {
+	tr.Columns = make([][]*TextSpan, len(tv.Columns))
+	for i, col := range tv.Columns {
+		tr.Columns[i] = tr.Page.GetProperty(col.ID())
+	}
+}
+
 func (c *Client) buildTableView(tv *TableView, res *QueryCollectionResponse) error {
 	cv := tv.CollectionView
 	collection := tv.Collection
@@ -256,25 +353,7 @@ func (c *Client) buildTableView(tv *TableView, res *QueryCollectionResponse) err
 		return fmt.Errorf("buildTableView: page: '%s', colleciton is nil, collection view id: '%s'", ToNoDashID(tv.Page.ID), cv.ID)
 	}
 
-	if cv.Format != nil && collection.Schema != nil {
-		idx := 0
-		for _, prop := range cv.Format.TableProperties {
-			if !prop.Visible {
-				continue
-			}
-			propName := prop.Property
-			schema := collection.Schema[propName]
-			ci := &ColumnInfo{
-				TableView: tv,
-
-				Index:    idx,
-				Property: prop,
-				Schema:   schema,
-			}
-			idx++
-			tv.Columns = append(tv.Columns, ci)
-		}
-	}
+	tv.buildColumns()
 
 	// blockIDs are IDs of page blocks
 	// each page represents one table row
@@ -293,6 +372,7 @@ func (c *Client) buildTableView(tv *TableView, res *QueryCollectionResponse) err
 				TableView: tv,
 				Page:      b,
 			}
+			tv.setRowColumns(tr)
 			tv.Rows = append(tv.Rows, tr)
 		}
 	}
@@ -300,8 +380,14 @@ func (c *Client) buildTableView(tv *TableView, res *QueryCollectionResponse) err
 	return nil
 }
 
-// FetchTableRows if limit > 1000, it will get first 1000 rows
+// FetchTableRows if limit > 1000, it will get first 1000 rows.
+// Use FetchAllTableRows to get all rows
 func (c *Client) FetchTableRows(tv *TableView, limits ...int) (*TableView, error) {
+	return c.FetchTableRowsCtx(context.Background(), tv, limits...)
+}
+
+// FetchTableRowsCtx is like FetchTableRows but can be cancelled with ctx
+func (c *Client) FetchTableRowsCtx(ctx context.Context, tv *TableView, limits ...int) (*TableView, error) {
 	if tv == nil {
 		return nil, errors.New("tableView is nil")
 	}
@@ -318,7 +404,7 @@ func (c *Client) FetchTableRows(tv *TableView, limits ...int) (*TableView, error
 	var rsp QueryCollectionResponse
 	var err error
 	apiURL := "/api/v3/queryCollection?src=change_group"
-	err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON)
+	err = c.doNotionAPI(ctx, apiURL, req, &rsp, &rsp.RawJSON)
 	if err != nil {
 		return nil, err
 	}
@@ -338,16 +424,43 @@ func (c *Client) FetchTableRows(tv *TableView, limits ...int) (*TableView, error
 	return tv, nil
 }
 
+// FetchTableRowsByIds fetches rows with given ids using space short id,
+// which doesn't require auth token. Rows belong to a TableView built
+// from their collection, showing all columns. Blocks that are not rows
+// of a collection (or whose collection is not accessible) are returned
+// without TableView and Columns. Use FetchTableViewRowsByIds
+// to get rows of an existing TableView
 func (c *Client) FetchTableRowsByIds(spaceShortId string, rowIds []string) ([]*TableRow, error) {
-	if len(rowIds) == 0 {
-		return nil, errors.New("rowIds is empty")
+	return c.FetchTableRowsByIdsCtx(context.Background(), spaceShortId, rowIds)
+}
+
+// FetchTableRowsByIdsCtx is like FetchTableRowsByIds but can be cancelled with ctx
+func (c *Client) FetchTableRowsByIdsCtx(ctx context.Context, spaceShortId string, rowIds []string) ([]*TableRow, error) {
+	return c.fetchTableRowsByIds(ctx, nil, spaceShortId, rowIds)
+}
+
+// FetchTableViewRowsByIds is like FetchTableRowsByIds but uses tv.SpaceShortId
+// and returned rows belong to tv, with Columns set
+func (c *Client) FetchTableViewRowsByIds(tv *TableView, rowIds []string) ([]*TableRow, error) {
+	return c.FetchTableViewRowsByIdsCtx(context.Background(), tv, rowIds)
+}
+
+// FetchTableViewRowsByIdsCtx is like FetchTableViewRowsByIds but can be cancelled with ctx
+func (c *Client) FetchTableViewRowsByIdsCtx(ctx context.Context, tv *TableView, rowIds []string) ([]*TableRow, error) {
+	if tv == nil {
+		return nil, errors.New("tableView is nil")
 	}
+	return c.fetchTableRowsByIds(ctx, tv, tv.SpaceShortId, rowIds)
+}
 
-	requests := make([]QueryCollectionBlockRequest, len(rowIds))
-	for i, id := range rowIds {
+// syncRecordValuesSpaceInitial gets records from a given table using
+// space short id, which doesn't require auth token
+func (c *Client) syncRecordValuesSpaceInitial(ctx context.Context, spaceShortId string, table string, ids []string) (*RecordMap, error) {
+	requests := make([]QueryCollectionBlockRequest, len(ids))
+	for i, id := range ids {
 		requests[i] = QueryCollectionBlockRequest{
 			Pointer: BlockPointer{
-				Table: "block",
+				Table: table,
 				ID:    id,
 			},
 			Version: -1,
@@ -358,36 +471,295 @@ func (c *Client) FetchTableRowsByIds(spaceShortId string, rowIds []string) ([]*T
 	}
 
 	var rsp QueryCollectionBlocksResponse
-	var err error
 	apiURL := "/api/v3/syncRecordValuesSpaceInitial"
 
 	header := http.Header{}
 	header.Set("x-notion-space-short-id", spaceShortId)
 	header.Set("x-notion-active-user-header", "")
 
-	err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON, header)
+	err := c.doNotionAPI(ctx, apiURL, req, &rsp, &rsp.RawJSON, header)
 	if err != nil {
 		return nil, err
 	}
-
+	if rsp.RecordMap == nil {
+		return nil, fmt.Errorf("no records found in response")
+	}
 	if err := ParseRecordMap(rsp.RecordMap); err != nil {
 		return nil, err
 	}
+	return rsp.RecordMap, nil
+}
+
+// newCollectionTableView returns a TableView of collection that
+// shows all its columns, title first
+func newCollectionTableView(collection *Collection) *TableView {
+	props, _ := viewProperties(collection, nil, nil)
+	tv := &TableView{
+		Collection: collection,
+		CollectionView: &CollectionView{
+			Type:   CollectionViewTypeTable,
+			Format: &FormatTable{TableProperties: props},
+		},
+	}
+	if collection.SpaceId != nil {
+		tv.SpaceId = *collection.SpaceId
+	}
+	tv.buildColumns()
+	return tv
+}
+
+// collectionTableViews returns table views of parent collections of rows,
+// by collection id. Collections not in recordMap are fetched. Blocks that
+// are not rows of a collection are skipped
+func (c *Client) collectionTableViews(ctx context.Context, spaceShortId string, recordMap *RecordMap, rows []*Block) (map[string]*TableView, error) {
+	res := map[string]*TableView{}
+	var missing []string
+	for _, b := range rows {
+		if b.ParentTable != TableCollection {
+			continue
+		}
+		if _, ok := res[b.ParentID]; ok {
+			continue
+		}
+		res[b.ParentID] = nil
+		if rec, ok := recordMap.Collections[b.ParentID]; ok && rec.Collection != nil {
+			res[b.ParentID] = newCollectionTableView(rec.Collection)
+		} else {
+			missing = append(missing, b.ParentID)
+		}
+	}
+	if len(missing) > 0 {
+		rm, err := c.syncRecordValuesSpaceInitial(ctx, spaceShortId, TableCollection, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			rec, ok := rm.Collections[id]
+			if !ok || rec.Collection == nil {
+				c.vlogf("collectionTableViews: collection '%s' doesn't exist or is not accessible\n", id)
+				continue
+			}
+			tv := newCollectionTableView(rec.Collection)
+			tv.SpaceShortId = spaceShortId
+			res[id] = tv
+		}
+	}
+	return res, nil
+}
+
+func (c *Client) fetchTableRowsByIds(ctx context.Context, tv *TableView, spaceShortId string, rowIds []string) ([]*TableRow, error) {
+	if len(rowIds) == 0 {
+		return nil, errors.New("rowIds is empty")
+	}
 
-	if len(rsp.RecordMap.Blocks) == 0 {
+	recordMap, err := c.syncRecordValuesSpaceInitial(ctx, spaceShortId, TableBlock, rowIds)
+	if err != nil {
+		return nil, err
+	}
+	if len(recordMap.Blocks) == 0 {
 		return nil, fmt.Errorf("no blocks found in response")
 	}
 
-	rows := make([]*TableRow, 0, len(rowIds))
+	var blocks []*Block
 	for _, id := range rowIds {
-		rec, ok := rsp.RecordMap.Blocks[id]
-		if !ok {
-			continue
+		if rec, ok := recordMap.Blocks[id]; ok && rec.Block != nil {
+			blocks = append(blocks, rec.Block)
+		}
+	}
+	// rows that don't belong to a TableView get a view of their collection
+	var views map[string]*TableView
+	if tv == nil {
+		views, err = c.collectionTableViews(ctx, spaceShortId, recordMap, blocks)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make([]*TableRow, 0, len(blocks))
+	for _, b := range blocks {
+		rowView := tv
+		if rowView == nil && b.ParentTable == TableCollection {
+			rowView = views[b.ParentID]
+		}
+		tr := &TableRow{
+			TableView: rowView,
+			Page:      b,
 		}
-		rows = append(rows, &TableRow{
-			Page: rec.Block,
-		})
+		if rowView != nil {
+			rowView.setRowColumns(tr)
+		}
+		rows = append(rows, tr)
+	}
+
+	return rows, nil
+}
+
+// number of rows we ask for in a single request when
+// fetching rows that were not returned by queryCollection
+const fetchTableRowsBatchSize = 500
+
+// FetchAllTableRows fetches all rows of the table view, not just the first page.
+// It re-queries the collection with the total number of rows reported by
+// the server and then fetches rows that were listed in RowIds but not returned
+// (e.g. beyond the first 1000 rows) with FetchTableRowsByIds or GetBlockRecords.
+// tv.Rows is in the order of tv.RowIds
+func (c *Client) FetchAllTableRows(tv *TableView) (*TableView, error) {
+	return c.FetchAllTableRowsCtx(context.Background(), tv)
+}
+
+// FetchAllTableRowsCtx is like FetchAllTableRows but can be cancelled with ctx
+func (c *Client) FetchAllTableRowsCtx(ctx context.Context, tv *TableView) (*TableView, error) {
+	if err := c.queryAllTableRows(ctx, tv); err != nil {
+		return nil, err
+	}
+	if err := c.loadAllTableRows(ctx, tv); err != nil {
+		return nil, err
+	}
+	return tv, nil
+}
+
+// ForEachTableRow is like FetchAllTableRows but calls fn for each row as
+// soon as it's fetched instead of accumulating them. Rows are not necessarily
+// in the order of tv.RowIds and tv.Rows only has rows returned by the
+// first query. Iteration stops when fn returns an error and the error is returned
+func (c *Client) ForEachTableRow(tv *TableView, fn func(*TableRow) error) error {
+	return c.ForEachTableRowCtx(context.Background(), tv, fn)
+}
+
+// ForEachTableRowCtx is like ForEachTableRow but can be cancelled with ctx
+func (c *Client) ForEachTableRowCtx(ctx context.Context, tv *TableView, fn func(*TableRow) error) error {
+	if err := c.queryAllTableRows(ctx, tv); err != nil {
+		return err
 	}
+	for _, tr := range tv.Rows {
+		if err := fn(tr); err != nil {
+			return err
+		}
+	}
+	return c.fetchMissingTableRows(ctx, tv, fn)
+}
 
+// queryAllTableRows re-builds tv from a query for all rows of its collection view
+func (c *Client) queryAllTableRows(ctx context.Context, tv *TableView) error {
+	if tv == nil {
+		return errors.New("tableView is nil")
+	}
+	// e.g. when collection failed to load
+	if tv.Collection == nil || tv.CollectionView == nil {
+		return errors.New("tableView has no collection or collection view")
+	}
+
+	req := QueryCollectionRequest{}
+	req.Collection.ID = tv.Collection.ID
+	req.Collection.SpaceID = tv.SpaceId
+	req.CollectionView.ID = tv.CollectionView.ID
+	req.CollectionView.SpaceID = tv.SpaceId
+	rsp, err := c.queryCollectionWithLimit(ctx, req, tv.CollectionView.Query, RowLimitAll)
+	if err != nil {
+		return err
+	}
+
+	tv.Columns = nil // reset columns
+	tv.Rows = nil    // reset rows
+	tv.SizeHint = rsp.Result.SizeHint
+	return c.buildTableView(tv, rsp)
+}
+
+// loadAllTableRows fetches rows in tv.RowIds that are not in tv.Rows
+// and puts tv.Rows in the order of tv.RowIds
+func (c *Client) loadAllTableRows(ctx context.Context, tv *TableView) error {
+	err := c.fetchMissingTableRows(ctx, tv, func(tr *TableRow) error {
+		tv.Rows = append(tv.Rows, tr)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	idToRow := map[string]*TableRow{}
+	for _, tr := range tv.Rows {
+		idToRow[tr.Page.ID] = tr
+	}
+	rows := make([]*TableRow, 0, len(tv.Rows))
+	for _, id := range tv.RowIds {
+		if tr, ok := idToRow[id]; ok {
+			rows = append(rows, tr)
+			delete(idToRow, id)
+		}
+	}
+	tv.Rows = rows
+	return nil
+}
+
+// fetchMissingTableRows fetches rows in tv.RowIds that are not in tv.Rows
+// and calls fn for each of them
+func (c *Client) fetchMissingTableRows(ctx context.Context, tv *TableView, fn func(*TableRow) error) error {
+	have := map[string]bool{}
+	for _, tr := range tv.Rows {
+		have[tr.Page.ID] = true
+	}
+	var missing []string
+	for _, id := range tv.RowIds {
+		if !have[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// without auth token, rows can only be fetched with space short id
+	if c.AuthToken == "" && tv.SpaceShortId == "" {
+		if tv.Page == nil {
+			return fmt.Errorf("can't fetch %d missing rows of collection view '%s' without Page", len(missing), tv.CollectionView.ID)
+		}
+		rsp, err := c.QuerySpaceShortIdCtx(ctx, tv.Page.ID, tv.CollectionView.ID)
+		if err != nil {
+			return err
+		}
+		tv.SpaceShortId = rsp.SpaceShortId
+	}
+
+	c.vlogf("fetchMissingTableRows: fetching %d missing rows of collection view '%s'\n", len(missing), tv.CollectionView.ID)
+	for len(missing) > 0 {
+		toGet := missing
+		if len(toGet) > fetchTableRowsBatchSize {
+			toGet = missing[:fetchTableRowsBatchSize]
+		}
+		missing = missing[len(toGet):]
+
+		rows, err := c.fetchTableRowsBatch(ctx, tv, toGet)
+		if err != nil {
+			return err
+		}
+		for _, tr := range rows {
+			if err := fn(tr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Client) fetchTableRowsBatch(ctx context.Context, tv *TableView, ids []string) ([]*TableRow, error) {
+	if c.AuthToken == "" {
+		return c.FetchTableViewRowsByIdsCtx(ctx, tv, ids)
+	}
+	blocks, err := c.GetBlockRecordsCtx(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	var rows []*TableRow
+	for _, b := range blocks {
+		// rows we don't have access to are nil
+		if b != nil {
+			tr := &TableRow{
+				TableView: tv,
+				Page:      b,
+			}
+			tv.setRowColumns(tr)
+			rows = append(rows, tr)
+		}
+	}
 	return rows, nil
 }
@@ -0,0 +1,529 @@
+package notionapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// colors of new select / multi-select options, picked in order
+var optionColors = []string{
+	ColorDefault,
+	ColorGray,
+	ColorBrown,
+	ColorOrange,
+	ColorYellow,
+	ColorTeal,
+	ColorBlue,
+	ColorPurple,
+	ColorPink,
+	ColorRed,
+}
+
+// SetRowPropertyOptions are options for SetRowPropertyWithOptions
+type SetRowPropertyOptions struct {
+	// if true, values of select / multi-select column that are not
+	// in ColumnSchema.Options are added as new options. If false,
+	// they are an error
+	CreateOptions bool
+}
+
+// columnIDsNamed returns sorted ids of columns with a given name.
+// Notion allows more than one column with the same name
+func (c *Collection) columnIDsNamed(name string) []string {
+	var res []string
+	for id, schema := range c.Schema {
+		if schema.Name == name {
+			res = append(res, id)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// hasColumn returns true if collection has a column with a given name or id
+func (c *Collection) hasColumn(name string) bool {
+	return c.Schema[name] != nil || len(c.columnIDsNamed(name)) > 0
+}
+
+// findColumn returns id and schema of a column with a given name.
+// As a fallback, name can be an id of the column. If more than one
+// column has the name, the column must be identified by its id
+func (c *Collection) findColumn(name string) (string, *ColumnSchema, error) {
+	ids := c.columnIDsNamed(name)
+	if len(ids) == 1 {
+		return ids[0], c.Schema[ids[0]], nil
+	}
+	if schema, ok := c.Schema[name]; ok {
+		return name, schema, nil
+	}
+	if len(ids) > 1 {
+		return "", nil, fmt.Errorf("collection '%s' has %d columns named '%s', use id of the column (one of %s)", c.ID, len(ids), name, strings.Join(ids, ", "))
+	}
+	return "", nil, fmt.Errorf("collection '%s' doesn't have column '%s'", c.ID, name)
+}
+
+// valueToStrings converts a string or []string value to []string
+func valueToStrings(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	}
+	return nil, fmt.Errorf("expected string or []string, got %T", value)
+}
+
+func numberToString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case string:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return "", fmt.Errorf("'%s' is not a number", v)
+		}
+		return v, nil
+	}
+	return "", fmt.Errorf("expected a number, got %T", value)
+}
+
+func timeToDate(t time.Time) *Date {
+	d := &Date{
+		StartDate: t.Format("2006-01-02"),
+		Type:      "date",
+	}
+	if t.Hour() != 0 || t.Minute() != 0 {
+		d.Type = "datetime"
+		d.StartTime = t.Format("15:04")
+	}
+	return d
+}
+
+// mentionsToSpans returns spans that mention users (attrType is AttrUser)
+// or pages (AttrPage), separated by commas, like Notion does it
+func mentionsToSpans(value interface{}, attrType string) ([]*TextSpan, error) {
+	ids, err := valueToStrings(value)
+	if err != nil {
+		return nil, err
+	}
+	rt := NewRichText()
+	for i, id := range ids {
+		if i > 0 {
+			rt.Text(",")
+		}
+		if attrType == AttrUser {
+			rt.User(id)
+		} else {
+			rt.Page(id)
+		}
+	}
+	return rt.Spans, nil
+}
+
+// selectOptions returns spans for value of select / multi-select column and
+// options that must be added to the schema
+func selectOptions(schema *ColumnSchema, value interface{}, create bool) ([]*TextSpan, []*CollectionColumnOption, error) {
+	values, err := valueToStrings(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if schema.Type == ColumnTypeSelect && len(values) > 1 {
+		return nil, nil, fmt.Errorf("select column '%s' can only have one value", schema.Name)
+	}
+	var newOptions []*CollectionColumnOption
+	for _, v := range values {
+		if v == "" || strings.Contains(v, ",") {
+			return nil, nil, fmt.Errorf("invalid option '%s'", v)
+		}
+		found := false
+		for _, o := range schema.Options {
+			found = found || o.Value == v
+		}
+		for _, o := range newOptions {
+			found = found || o.Value == v
+		}
+		if found {
+			continue
+		}
+		if !create {
+			return nil, nil, fmt.Errorf("'%s' is not a valid option of column '%s'", v, schema.Name)
+		}
+		n := len(schema.Options) + len(newOptions)
+		newOptions = append(newOptions, newColumnOption(v, n))
+	}
+	return plainText(strings.Join(values, ",")), newOptions, nil
+}
+
+// newColumnOption returns a new option of select / multi-select column.
+// n is the number of existing options and determines its color
+func newColumnOption(value string, n int) *CollectionColumnOption {
+	return &CollectionColumnOption{
+		ID:    uuid.New().String(),
+		Value: value,
+		Color: optionColors[n%len(optionColors)],
+	}
+}
+
+// encodePropertyValue converts value to text spans in the format expected
+// for a column of a given schema
+func encodePropertyValue(schema *ColumnSchema, value interface{}, create bool) ([]*TextSpan, []*CollectionColumnOption, error) {
+	switch schema.Type {
+	case ColumnTypeTitle, ColumnTypeText:
+		switch v := value.(type) {
+		case string:
+			return plainText(v), nil, nil
+		case []*TextSpan:
+			return v, nil, nil
+		case *RichText:
+			return v.Spans, nil, nil
+		}
+		return nil, nil, fmt.Errorf("expected string, []*TextSpan or *RichText, got %T", value)
+	case ColumnTypeURL, ColumnTypeEmail, ColumnTypePhoneNumber:
+		v, ok := value.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected string, got %T", value)
+		}
+		return plainText(v), nil, nil
+	case ColumnTypeCheckbox:
+		v, ok := value.(bool)
+		if !ok {
+			return nil, nil, fmt.Errorf("expected bool, got %T", value)
+		}
+		if v {
+			return plainText("Yes"), nil, nil
+		}
+		return plainText("No"), nil, nil
+	case ColumnTypeNumber:
+		s, err := numberToString(value)
+		return plainText(s), nil, err
+	case ColumnTypeSelect, ColumnTypeMultiSelect:
+		return selectOptions(schema, value, create)
+	case ColumnTypeDate:
+		var d *Date
+		switch v := value.(type) {
+		case *Date:
+			d = v
+		case Date:
+			d = &v
+		case time.Time:
+			d = timeToDate(v)
+		default:
+			return nil, nil, fmt.Errorf("expected *Date, Date or time.Time, got %T", value)
+		}
+		rt := NewRichText().Date(d)
+		return rt.Spans, nil, rt.Err()
+	case ColumnTypePerson:
+		spans, err := mentionsToSpans(value, AttrUser)
+		return spans, nil, err
+	case ColumnTypeRelation:
+		spans, err := mentionsToSpans(value, AttrPage)
+		return spans, nil, err
+	}
+	return nil, nil, fmt.Errorf("can't set value of column of type '%s'", schema.Type)
+}
+
+// rowPropertyOps returns operations that set value of a column of the row.
+// Returns raw value of the property and new options, which are
+// applied to the row and schema after the operations are submitted
+func rowPropertyOps(row *TableRow, columnName string, value interface{}, opts *SetRowPropertyOptions) ([]*Operation, []interface{}, []*CollectionColumnOption, error) {
+	if row == nil || row.Page == nil {
+		return nil, nil, nil, errors.New("row is nil")
+	}
+	if row.TableView == nil || row.TableView.Collection == nil {
+		return nil, nil, nil, errors.New("row doesn't have a collection")
+	}
+	collection := row.TableView.Collection
+	colID, schema, err := collection.findColumn(columnName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var raw []interface{}
+	var newOptions []*CollectionColumnOption
+	if value != nil {
+		spans, options, err := encodePropertyValue(schema, value, opts != nil && opts.CreateOptions)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("column '%s': %w", schema.Name, err)
+		}
+		raw, err = TextSpansToRaw(spans)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("column '%s': %w", schema.Name, err)
+		}
+		newOptions = options
+	}
+
+	var ops []*Operation
+	if len(newOptions) > 0 {
+		options := append(append([]*CollectionColumnOption{}, schema.Options...), newOptions...)
+		ops = append(ops, &Operation{
+			ID:      collection.ID,
+			Table:   TableCollection,
+			Path:    []string{"schema", colID, "options"},
+			Command: CommandSet,
+			Args:    options,
+		})
+	}
+	var args interface{}
+	if raw != nil {
+		args = raw
+	}
+	ops = append(ops, row.Page.buildOp(CommandSet, []string{"properties", colID}, args))
+	return ops, raw, newOptions, nil
+}
+
+// SetRowProperty sets value of a column (property) of a collection (database) row.
+// The value is encoded according to the type of the column:
+//   - title, text: string, []*TextSpan or *RichText
+//   - checkbox: bool
+//   - number: int, float64 etc.
+//   - select, multi-select: string or []string with values of options
+//   - date: *Date or time.Time
+//   - person: id or []string ids of users
+//   - relation: id or []string ids of pages (rows in related collection)
+//   - url, email, phone_number: string
+//
+// nil value clears the property
+func (c *Client) SetRowProperty(row *TableRow, columnName string, value interface{}) error {
+	return c.SetRowPropertyWithOptionsCtx(context.Background(), row, columnName, value, nil)
+}
+
+// SetRowPropertyCtx is like SetRowProperty but can be cancelled with ctx
+func (c *Client) SetRowPropertyCtx(ctx context.Context, row *TableRow, columnName string, value interface{}) error {
+	return c.SetRowPropertyWithOptionsCtx(ctx, row, columnName, value, nil)
+}
+
+// SetRowPropertyWithOptions is like SetRowProperty but with options
+func (c *Client) SetRowPropertyWithOptions(row *TableRow, columnName string, value interface{}, opts *SetRowPropertyOptions) error {
+	return c.SetRowPropertyWithOptionsCtx(context.Background(), row, columnName, value, opts)
+}
+
+// SetRowPropertyWithOptionsCtx is like SetRowPropertyWithOptions but can be cancelled with ctx
+func (c *Client) SetRowPropertyWithOptionsCtx(ctx context.Context, row *TableRow, columnName string, value interface{}, opts *SetRowPropertyOptions) error {
+	ops, raw, newOptions, err := rowPropertyOps(row, columnName, value, opts)
+	if err != nil {
+		return err
+	}
+	tx := NewTransaction().Add(ops...)
+	if err = c.CommitTransactionCtx(ctx, tx); err != nil {
+		return err
+	}
+
+	// reflect the change in the row and schema
+	colID, schema, _ := row.TableView.Collection.findColumn(columnName)
+	schema.Options = append(schema.Options, newOptions...)
+	if row.Page.Properties == nil {
+		row.Page.Properties = map[string]interface{}{}
+	}
+	if raw == nil {
+		delete(row.Page.Properties, colID)
+	} else {
+		row.Page.Properties[colID] = raw
+	}
+	row.TableView.setRowColumns(row)
+	return nil
+}
+
+// newRowOp returns an operation that creates a new row (a page) in collection
+// with values of columns given by names
+func newRowOp(collection *Collection, spaceID string, rowID string, now int64, values map[string]interface{}) (*Operation, error) {
+	props := map[string]interface{}{}
+	for name, value := range values {
+		if value == nil {
+			continue
+		}
+		colID, schema, err := collection.findColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		spans, _, err := encodePropertyValue(schema, value, false)
+		if err != nil {
+			return nil, fmt.Errorf("column '%s': %w", schema.Name, err)
+		}
+		if props[colID], err = TextSpansToRaw(spans); err != nil {
+			return nil, fmt.Errorf("column '%s': %w", schema.Name, err)
+		}
+	}
+	args := map[string]interface{}{
+		"id":               rowID,
+		"version":          1,
+		"alive":            true,
+		"type":             BlockPage,
+		"parent_id":        collection.ID,
+		"parent_table":     TableCollection,
+		"space_id":         spaceID,
+		"created_time":     now,
+		"last_edited_time": now,
+		"properties":       props,
+	}
+	row := &Block{ID: rowID}
+	return row.buildOp(CommandSet, []string{}, args), nil
+}
+
+// addCollectionRow creates a new row in collection and returns its page block
+func (c *Client) addCollectionRow(ctx context.Context, collection *Collection, spaceID string, values map[string]interface{}) (*Block, error) {
+	tx := NewTransaction()
+	op, err := newRowOp(collection, spaceID, uuid.New().String(), tx.now, values)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.CommitTransactionCtx(ctx, tx.Add(op)); err != nil {
+		return nil, err
+	}
+	args := op.Args.(map[string]interface{})
+	return &Block{
+		ID:             op.ID,
+		Alive:          true,
+		Type:           BlockPage,
+		ParentID:       collection.ID,
+		ParentTable:    TableCollection,
+		SpaceID:        spaceID,
+		Version:        1,
+		CreatedTime:    tx.now,
+		LastEditedTime: tx.now,
+		Properties:     args["properties"].(map[string]interface{}),
+		RawJSON:        args,
+	}, nil
+}
+
+// AddCollectionRow adds a new row (entry) to a collection (database).
+// values are keyed by column names and encoded like in SetRowProperty.
+// Returns id of the new row (a page)
+func (c *Client) AddCollectionRow(collectionID string, values map[string]interface{}) (string, error) {
+	return c.AddCollectionRowCtx(context.Background(), collectionID, values)
+}
+
+// getCollection returns a collection with a given id or an error if it doesn't exist
+func (c *Client) getCollection(ctx context.Context, collectionID string) (*Collection, error) {
+	collections, err := c.GetCollectionRecordsCtx(ctx, []string{collectionID})
+	if err != nil {
+		return nil, err
+	}
+	if len(collections) == 0 || collections[0] == nil {
+		return nil, fmt.Errorf("collection '%s' doesn't exist or is not accessible", collectionID)
+	}
+	return collections[0], nil
+}
+
+// AddCollectionRowCtx is like AddCollectionRow but can be cancelled with ctx
+func (c *Client) AddCollectionRowCtx(ctx context.Context, collectionID string, values map[string]interface{}) (string, error) {
+	collection, err := c.getCollection(ctx, collectionID)
+	if err != nil {
+		return "", err
+	}
+	var spaceID string
+	if collection.SpaceId != nil {
+		spaceID = *collection.SpaceId
+	} else {
+		parent, err := c.getBlock(ctx, collection.ParentID)
+		if err != nil {
+			return "", err
+		}
+		spaceID = parent.SpaceID
+	}
+	row, err := c.addCollectionRow(ctx, collection, spaceID, values)
+	if err != nil {
+		return "", err
+	}
+	return row.ID, nil
+}
+
+// DeleteCollectionRow deletes a row (entry) of a collection (database).
+// Like ArchiveBlock, it can be undone with RestoreBlock
+func (c *Client) DeleteCollectionRow(rowID string) error {
+	return c.DeleteCollectionRowCtx(context.Background(), rowID)
+}
+
+// DeleteCollectionRowCtx is like DeleteCollectionRow but can be cancelled with ctx
+func (c *Client) DeleteCollectionRowCtx(ctx context.Context, rowID string) error {
+	b, err := c.getBlock(ctx, ToDashID(rowID))
+	if err != nil {
+		return err
+	}
+	if b.ParentTable != TableCollection {
+		return fmt.Errorf("block '%s' is not a row of a collection", b.ID)
+	}
+	tx := NewTransaction().Add(archiveBlockOps(b)...)
+	return c.CommitTransactionCtx(ctx, tx)
+}
+
+// AddTableRow is like AddCollectionRow but adds the row to the collection of
+// table view tv and appends it to tv.Rows. The position of the row in the view
+// (which depends on its sorting and filters) is only known after re-fetching
+// rows with FetchAllTableRows
+func (c *Client) AddTableRow(tv *TableView, values map[string]interface{}) (*TableRow, error) {
+	return c.AddTableRowCtx(context.Background(), tv, values)
+}
+
+// AddTableRowCtx is like AddTableRow but can be cancelled with ctx
+func (c *Client) AddTableRowCtx(ctx context.Context, tv *TableView, values map[string]interface{}) (*TableRow, error) {
+	if tv == nil || tv.Collection == nil {
+		return nil, errors.New("tableView doesn't have a collection")
+	}
+	spaceID := tv.SpaceId
+	if spaceID == "" && tv.Collection.SpaceId != nil {
+		spaceID = *tv.Collection.SpaceId
+	}
+	if spaceID == "" && tv.CollectionView != nil {
+		spaceID = tv.CollectionView.SpaceID
+	}
+	b, err := c.addCollectionRow(ctx, tv.Collection, spaceID, values)
+	if err != nil {
+		return nil, err
+	}
+	tr := &TableRow{
+		TableView: tv,
+		Page:      b,
+	}
+	tv.setRowColumns(tr)
+	tv.Rows = append(tv.Rows, tr)
+	tv.RowIds = append(tv.RowIds, b.ID)
+	return tr, nil
+}
+
+// DeleteTableRow deletes a row with DeleteCollectionRow and removes it from
+// its table view
+func (c *Client) DeleteTableRow(row *TableRow) error {
+	return c.DeleteTableRowCtx(context.Background(), row)
+}
+
+// DeleteTableRowCtx is like DeleteTableRow but can be cancelled with ctx
+func (c *Client) DeleteTableRowCtx(ctx context.Context, row *TableRow) error {
+	if row == nil || row.Page == nil {
+		return errors.New("row is nil")
+	}
+	tx := NewTransaction().Add(archiveBlockOps(row.Page)...)
+	if err := c.CommitTransactionCtx(ctx, tx); err != nil {
+		return err
+	}
+	row.Page.Alive = false
+	tv := row.TableView
+	if tv == nil {
+		return nil
+	}
+	for i, tr := range tv.Rows {
+		if tr == row {
+			tv.Rows = append(tv.Rows[:i], tv.Rows[i+1:]...)
+			break
+		}
+	}
+	for i, id := range tv.RowIds {
+		if id == row.Page.ID {
+			tv.RowIds = append(tv.RowIds[:i], tv.RowIds[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
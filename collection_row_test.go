@@ -0,0 +1,279 @@
+package notionapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kjk/common/assert"
+)
+
+func newTestTableRow() *TableRow {
+	collection := &Collection{
+		ID: testBlockID(50),
+		Schema: map[string]*ColumnSchema{
+			"title": {Name: "Name", Type: ColumnTypeTitle},
+			"AbCd":  {Name: "Done", Type: ColumnTypeCheckbox},
+			"nmbr":  {Name: "Price", Type: ColumnTypeNumber},
+			"slct": {Name: "Status", Type: ColumnTypeSelect, Options: []*CollectionColumnOption{
+				{ID: "o1", Value: "Todo", Color: ColorRed},
+				{ID: "o2", Value: "Done", Color: ColorBlue},
+			}},
+			"tags": {Name: "Tags", Type: ColumnTypeMultiSelect, Options: []*CollectionColumnOption{
+				{ID: "o3", Value: "go", Color: ColorRed},
+			}},
+			"date": {Name: "Due", Type: ColumnTypeDate},
+			"prsn": {Name: "Owner", Type: ColumnTypePerson},
+			"rltn": {Name: "Related", Type: ColumnTypeRelation},
+			"url_": {Name: "Link", Type: ColumnTypeURL},
+			"frml": {Name: "Formula", Type: ColumnTypeFormula},
+		},
+	}
+	return &TableRow{
+		TableView: &TableView{Collection: collection},
+		Page:      &Block{ID: testBlockID(51), ParentTable: TableCollection},
+	}
+}
+
+func TestFindColumn(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	id, schema, err := collection.findColumn("Price")
+	assert.NoError(t, err)
+	assert.Equal(t, "nmbr", id)
+	assert.Equal(t, ColumnTypeNumber, schema.Type)
+	id, _, err = collection.findColumn("nmbr")
+	assert.NoError(t, err)
+	assert.Equal(t, "nmbr", id)
+
+	// column names don't have to be unique, ids do
+	collection.Schema["nmb2"] = &ColumnSchema{Name: "Price", Type: ColumnTypeText}
+	_, _, err = collection.findColumn("Price")
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "nmb2, nmbr"))
+	id, _, err = collection.findColumn("nmb2")
+	assert.NoError(t, err)
+	assert.Equal(t, "nmb2", id)
+	assert.True(t, collection.hasColumn("Price"))
+	assert.False(t, collection.hasColumn("Missing"))
+}
+
+func TestRowPropertyOps(t *testing.T) {
+	row := newTestTableRow()
+	d := &Date{StartDate: "2021-03-04", Type: "date"}
+	tests := []struct {
+		column string
+		value  interface{}
+		colID  string
+		exp    []interface{}
+	}{
+		{"Name", "row title", "title", spans("row title", "")},
+		{"Name", NewRichText().Bold("bold"), "title", spans("bold", "b")},
+		{"Done", true, "AbCd", spans("Yes", "")},
+		{"Done", false, "AbCd", spans("No", "")},
+		{"Price", 12.5, "nmbr", spans("12.5", "")},
+		{"Price", 3, "nmbr", spans("3", "")},
+		{"Status", "Done", "slct", spans("Done", "")},
+		{"Tags", []string{"go"}, "tags", spans("go", "")},
+		{"Link", "https://example.com", "url_", spans("https://example.com", "")},
+		{"Owner", []string{"user-1", "user-2"}, "prsn", spans("‣", "u user-1", ",", "", "‣", "u user-2")},
+		{"Related", ToNoDashID(testBlockID(7)), "rltn", spans("‣", "p "+testBlockID(7))},
+		// column id works as well
+		{"nmbr", "7", "nmbr", spans("7", "")},
+	}
+	for _, tc := range tests {
+		ops, raw, newOptions, err := rowPropertyOps(row, tc.column, tc.value, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(newOptions))
+		assert.Equal(t, 1, len(ops))
+		op := ops[0]
+		assert.Equal(t, row.Page.ID, op.ID)
+		assert.Equal(t, CommandSet, op.Command)
+		assert.Equal(t, []string{"properties", tc.colID}, op.Path)
+		assert.Equal(t, tc.exp, jsonRoundTrip(t, raw))
+	}
+
+	for _, v := range []interface{}{d, *d, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)} {
+		_, raw, _, err := rowPropertyOps(row, "Due", v, nil)
+		assert.NoError(t, err)
+		ts, err := ParseTextSpans(jsonRoundTrip(t, raw))
+		assert.NoError(t, err)
+		// default date format is added
+		exp := *d
+		exp.DateFormat = "relative"
+		assert.Equal(t, &exp, AttrGetDate(ts[0].Attrs[0]))
+	}
+
+	// clearing a property
+	ops, raw, _, err := rowPropertyOps(row, "Due", nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, raw)
+	assert.Nil(t, ops[0].Args)
+}
+
+func TestRowPropertyOpsErrors(t *testing.T) {
+	row := newTestTableRow()
+	tests := []struct {
+		column string
+		value  interface{}
+	}{
+		{"Missing", "x"},
+		{"Formula", "x"},
+		{"Done", "yes"},
+		{"Price", "abc"},
+		{"Status", "Unknown"},
+		{"Status", []string{"Todo", "Done"}},
+		{"Tags", []string{"a,b"}},
+		{"Due", "2021-03-04"},
+		{"Due", &Date{StartDate: "March 4"}},
+		{"Owner", 5},
+	}
+	for _, tc := range tests {
+		_, _, _, err := rowPropertyOps(row, tc.column, tc.value, nil)
+		assert.Error(t, err)
+	}
+	_, _, _, err := rowPropertyOps(&TableRow{Page: &Block{}}, "Name", "x", nil)
+	assert.Error(t, err)
+}
+
+func TestSetRowPropertyCreateOptions(t *testing.T) {
+	row := newTestTableRow()
+	var submitted submitTransactionRequest
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			err := jsonit.Unmarshal(body, &submitted)
+			return []byte("{}"), err
+		},
+	}
+	opts := &SetRowPropertyOptions{CreateOptions: true}
+	err := c.SetRowPropertyWithOptions(row, "Tags", []string{"go", "rust", "zig"}, opts)
+	assert.NoError(t, err)
+
+	// options, property and last edited time of the row
+	ops := submitted.Operations
+	assert.Equal(t, 3, len(ops))
+	assert.Equal(t, TableCollection, ops[0].Table)
+	assert.Equal(t, []string{"schema", "tags", "options"}, ops[0].Path)
+	assert.Equal(t, 3, len(ops[0].Args.([]interface{})))
+	assert.Equal(t, []string{"properties", "tags"}, ops[1].Path)
+
+	schema := row.TableView.Collection.Schema["tags"]
+	assert.Equal(t, 3, len(schema.Options))
+	assert.Equal(t, "rust", schema.Options[1].Value)
+	assert.Equal(t, ColorGray, schema.Options[1].Color)
+	assert.Equal(t, spans("go,rust,zig", ""), jsonRoundTrip(t, row.Page.Properties["tags"]))
+
+	err = c.SetRowProperty(row, "Tags", "scala")
+	assert.Error(t, err)
+	err = c.SetRowProperty(row, "Tags", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, row.Page.Properties["tags"])
+}
+
+func TestNewRowOp(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	values := map[string]interface{}{
+		"Name":   "new row",
+		"Done":   true,
+		"Status": "Todo",
+		"Due":    nil,
+	}
+	op, err := newRowOp(collection, testSpaceID, testBlockID(60), 1600000000000, values)
+	assert.NoError(t, err)
+	assert.Equal(t, testBlockID(60), op.ID)
+	assert.Equal(t, CommandSet, op.Command)
+	args := op.Args.(map[string]interface{})
+	assert.Equal(t, BlockPage, args["type"])
+	assert.Equal(t, collection.ID, args["parent_id"])
+	assert.Equal(t, TableCollection, args["parent_table"])
+	exp := map[string]interface{}{
+		"title": spans("new row", ""),
+		"AbCd":  spans("Yes", ""),
+		"slct":  spans("Todo", ""),
+	}
+	assert.Equal(t, exp, jsonRoundTrip(t, args["properties"]))
+
+	// rows are not in content of a block so no last edited time updates
+	tx := NewTransaction().Add(op)
+	assert.NoError(t, tx.Validate())
+	assert.Equal(t, 1, len(tx.Operations()))
+
+	_, err = newRowOp(collection, testSpaceID, testBlockID(60), 0, map[string]interface{}{"Missing": "x"})
+	assert.Error(t, err)
+	_, err = newRowOp(collection, testSpaceID, testBlockID(60), 0, map[string]interface{}{"Status": "Unknown"})
+	assert.Error(t, err)
+}
+
+func TestAddCollectionRow(t *testing.T) {
+	collectionID := testBlockID(50)
+	var submitted []*Operation
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			if strings.Contains(uri, "/api/v3/syncRecordValues") {
+				rsp := map[string]interface{}{
+					"recordMap": map[string]interface{}{
+						"collection": map[string]interface{}{
+							collectionID: map[string]interface{}{
+								"role": "editor",
+								"value": map[string]interface{}{
+									"id":       collectionID,
+									"space_id": testSpaceID,
+									"schema": map[string]interface{}{
+										"title": map[string]interface{}{"name": "Name", "type": "title"},
+									},
+								},
+							},
+						},
+					},
+				}
+				return jsonit.Marshal(rsp)
+			}
+			var req submitTransactionRequest
+			err := jsonit.Unmarshal(body, &req)
+			submitted = append(submitted, req.Operations...)
+			return []byte("{}"), err
+		},
+	}
+	id, err := c.AddCollectionRow(ToNoDashID(collectionID), map[string]interface{}{"Name": "row"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(submitted))
+	assert.Equal(t, id, submitted[0].ID)
+	args := submitted[0].Args.(map[string]interface{})
+	assert.Equal(t, testSpaceID, args["space_id"])
+	assert.Equal(t, collectionID, args["parent_id"])
+}
+
+func TestAddDeleteTableRow(t *testing.T) {
+	var submitted []*Operation
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			var req submitTransactionRequest
+			err := jsonit.Unmarshal(body, &req)
+			submitted = append(submitted, req.Operations...)
+			return []byte("{}"), err
+		},
+	}
+	row := newTestTableRow()
+	tv := row.TableView
+	tv.SpaceId = testSpaceID
+	tv.Rows = []*TableRow{row}
+	tv.RowIds = []string{row.Page.ID}
+
+	tr, err := c.AddTableRow(tv, map[string]interface{}{"Name": "added", "Price": 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(tv.Rows))
+	assert.Equal(t, []string{row.Page.ID, tr.Page.ID}, tv.RowIds)
+	assert.Equal(t, tv, tr.TableView)
+	assert.Equal(t, spans("5", ""), jsonRoundTrip(t, tr.Page.Properties["nmbr"]))
+	assert.Equal(t, 1, len(submitted))
+
+	err = c.DeleteTableRow(row)
+	assert.NoError(t, err)
+	assert.Equal(t, []*TableRow{tr}, tv.Rows)
+	assert.Equal(t, []string{tr.Page.ID}, tv.RowIds)
+	// alive=false and last edited time of the row
+	assert.Equal(t, 3, len(submitted))
+	assert.Equal(t, map[string]interface{}{"alive": false}, submitted[1].Args)
+	assert.Equal(t, row.Page.ID, submitted[1].ID)
+}
@@ -0,0 +1,403 @@
+package notionapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// types of columns that can be created with AddColumn and ChangeColumnType
+var creatableColumnTypes = map[string]bool{
+	ColumnTypeText:           true,
+	ColumnTypeNumber:         true,
+	ColumnTypeSelect:         true,
+	ColumnTypeMultiSelect:    true,
+	ColumnTypeDate:           true,
+	ColumnTypePerson:         true,
+	ColumnTypeFile:           true,
+	ColumnTypeCheckbox:       true,
+	ColumnTypeURL:            true,
+	ColumnTypeEmail:          true,
+	ColumnTypePhoneNumber:    true,
+	ColumnTypeFormula:        true,
+	ColumnTypeRelation:       true,
+	ColumnTypeRollup:         true,
+	ColumnTypeCreatedTime:    true,
+	ColumnTypeCreatedBy:      true,
+	ColumnTypeLastEditedTime: true,
+	ColumnTypeLastEditedBy:   true,
+}
+
+// ColumnOptions are type-specific settings of a column for AddColumn
+// and ChangeColumnType
+type ColumnOptions struct {
+	// for ColumnTypeSelect and ColumnTypeMultiSelect, values of options
+	Options []string
+
+	// for ColumnTypeNumber, e.g. "dollar", "number"
+	NumberFormat string
+
+	// for ColumnTypeRelation, id of the related collection
+	CollectionID string
+
+	// for ColumnTypeRollup, name (or id) of the relation column,
+	// name (or id) of the column in related collection and
+	// aggregation, e.g. "sum" (see TableRow.Rollup). Related collection
+	// is fetched to resolve the name of the column to its id
+	RelationProperty string
+	TargetProperty   string
+	Aggregation      string
+
+	// for ColumnTypeFormula
+	Formula *ColumnFormula
+}
+
+// characters of generated column ids
+const columnIDChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// newColumnID returns a random 4 character column id, like the ones
+// Notion generates, that is not used in the collection
+func newColumnID(collection *Collection) string {
+	for {
+		b := make([]byte, 4)
+		for i := range b {
+			b[i] = columnIDChars[rand.Intn(len(columnIDChars))]
+		}
+		id := string(b)
+		if _, exists := collection.Schema[id]; !exists && id != "title" {
+			return id
+		}
+	}
+}
+
+// collectionLoader returns a collection with a given id. It's used to
+// resolve names of columns in related collections
+type collectionLoader func(collectionID string) (*Collection, error)
+
+// collectionLoader returns a collectionLoader that fetches collections
+func (c *Client) collectionLoader(ctx context.Context) collectionLoader {
+	return func(collectionID string) (*Collection, error) {
+		return c.getCollection(ctx, collectionID)
+	}
+}
+
+// schemaOp returns an operation on schema of collection
+func schemaOp(collection *Collection, command string, path []string, args interface{}) *Operation {
+	return &Operation{
+		ID:      collection.ID,
+		Table:   TableCollection,
+		Path:    append([]string{"schema"}, path...),
+		Command: command,
+		Args:    args,
+	}
+}
+
+// columnSchemaToRaw converts schema to JSON sent to Notion, only with
+// fields relevant for the column type
+func columnSchemaToRaw(schema *ColumnSchema) map[string]interface{} {
+	res := map[string]interface{}{
+		"name": schema.Name,
+		"type": schema.Type,
+	}
+	switch schema.Type {
+	case ColumnTypeSelect, ColumnTypeMultiSelect:
+		options := schema.Options
+		if options == nil {
+			options = []*CollectionColumnOption{}
+		}
+		res["options"] = options
+	case ColumnTypeNumber:
+		if schema.NumberFormat != "" {
+			res["number_format"] = schema.NumberFormat
+		}
+	case ColumnTypeRelation:
+		res["collection_id"] = schema.CollectionID
+		if schema.Property != "" {
+			res["property"] = schema.Property
+		}
+	case ColumnTypeRollup:
+		res["relation_property"] = schema.RelationProperty
+		res["target_property"] = schema.TargetProperty
+		res["aggregation"] = schema.Aggregation
+		if schema.TargetPropertyType != "" {
+			res["target_property_type"] = schema.TargetPropertyType
+		}
+	case ColumnTypeFormula:
+		res["formula"] = schema.Formula
+	}
+	return res
+}
+
+// applyColumnOptions sets type-specific fields of schema from opts.
+// load is used to get the related collection of rollup columns
+func applyColumnOptions(collection *Collection, schema *ColumnSchema, opts *ColumnOptions, load collectionLoader) error {
+	if opts == nil {
+		opts = &ColumnOptions{}
+	}
+	switch schema.Type {
+	case ColumnTypeSelect, ColumnTypeMultiSelect:
+		options, err := mergeColumnOptions(schema.Options, opts.Options)
+		if err != nil {
+			return err
+		}
+		schema.Options = options
+	case ColumnTypeNumber:
+		schema.NumberFormat = opts.NumberFormat
+	case ColumnTypeRelation:
+		if opts.CollectionID == "" {
+			return fmt.Errorf("relation column '%s' needs CollectionID", schema.Name)
+		}
+		schema.CollectionID = ToDashID(opts.CollectionID)
+	case ColumnTypeRollup:
+		relID, relSchema, err := collection.findColumn(opts.RelationProperty)
+		if err != nil {
+			return err
+		}
+		if relSchema.Type != ColumnTypeRelation {
+			return fmt.Errorf("column '%s' of rollup '%s' is not a relation", relSchema.Name, schema.Name)
+		}
+		if opts.TargetProperty == "" || opts.Aggregation == "" {
+			return fmt.Errorf("rollup column '%s' needs TargetProperty and Aggregation", schema.Name)
+		}
+		if relSchema.CollectionID == "" {
+			return fmt.Errorf("relation column '%s' of rollup '%s' has no collection", relSchema.Name, schema.Name)
+		}
+		if load == nil {
+			return fmt.Errorf("can't resolve target of rollup column '%s'", schema.Name)
+		}
+		// Notion expects id of the column in related collection
+		related, err := load(relSchema.CollectionID)
+		if err != nil {
+			return err
+		}
+		targetID, targetSchema, err := related.findColumn(opts.TargetProperty)
+		if err != nil {
+			return err
+		}
+		schema.RelationProperty = relID
+		schema.TargetProperty = targetID
+		schema.TargetPropertyType = targetSchema.Type
+		schema.Aggregation = opts.Aggregation
+	case ColumnTypeFormula:
+		if opts.Formula == nil {
+			return fmt.Errorf("formula column '%s' needs Formula", schema.Name)
+		}
+		schema.Formula = opts.Formula
+	}
+	return nil
+}
+
+// mergeColumnOptions returns options with given values. Existing options
+// keep their ids and colors, new ones get new ids and colors
+func mergeColumnOptions(existing []*CollectionColumnOption, values []string) ([]*CollectionColumnOption, error) {
+	res := []*CollectionColumnOption{}
+	seen := map[string]bool{}
+	for _, v := range values {
+		if v == "" || seen[v] {
+			return nil, fmt.Errorf("invalid or duplicate option '%s'", v)
+		}
+		seen[v] = true
+		var option *CollectionColumnOption
+		for _, o := range existing {
+			if o.Value == v {
+				option = o
+			}
+		}
+		if option == nil {
+			option = newColumnOption(v, len(res))
+		}
+		res = append(res, option)
+	}
+	return res, nil
+}
+
+// addColumnOp returns an operation that adds a column to collection
+// and id of the column
+func addColumnOp(collection *Collection, name string, colType string, opts *ColumnOptions, load collectionLoader) (*Operation, string, error) {
+	if name == "" {
+		return nil, "", errors.New("column name is empty")
+	}
+	if collection.hasColumn(name) {
+		return nil, "", fmt.Errorf("collection '%s' already has column '%s'", collection.ID, name)
+	}
+	if !creatableColumnTypes[colType] {
+		return nil, "", fmt.Errorf("can't create a column of type '%s'", colType)
+	}
+	schema := &ColumnSchema{
+		Name: name,
+		Type: colType,
+	}
+	if err := applyColumnOptions(collection, schema, opts, load); err != nil {
+		return nil, "", err
+	}
+	colID := newColumnID(collection)
+	return schemaOp(collection, CommandSet, []string{colID}, columnSchemaToRaw(schema)), colID, nil
+}
+
+// changeColumnTypeOp returns an operation that changes type of a column
+func changeColumnTypeOp(collection *Collection, columnName string, colType string, opts *ColumnOptions, load collectionLoader) (*Operation, error) {
+	colID, schema, err := collection.findColumn(columnName)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Type == ColumnTypeTitle {
+		return nil, fmt.Errorf("can't change type of title column '%s'", schema.Name)
+	}
+	if !creatableColumnTypes[colType] {
+		return nil, fmt.Errorf("can't change type of column '%s' to '%s'", schema.Name, colType)
+	}
+	isSelect := colType == ColumnTypeSelect || colType == ColumnTypeMultiSelect
+	if isSelect && (opts == nil || opts.Options == nil) {
+		// options are preserved when changing between select and multi-select
+		o := ColumnOptions{}
+		if opts != nil {
+			o = *opts
+		}
+		for _, option := range schema.Options {
+			o.Options = append(o.Options, option.Value)
+		}
+		opts = &o
+	}
+	newSchema := &ColumnSchema{
+		Name:    schema.Name,
+		Type:    colType,
+		Options: schema.Options,
+	}
+	if err := applyColumnOptions(collection, newSchema, opts, load); err != nil {
+		return nil, err
+	}
+	return schemaOp(collection, CommandSet, []string{colID}, columnSchemaToRaw(newSchema)), nil
+}
+
+// deleteColumnOp returns an operation that deletes a column from collection
+func deleteColumnOp(collection *Collection, columnName string) (*Operation, error) {
+	colID, schema, err := collection.findColumn(columnName)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Type == ColumnTypeTitle {
+		return nil, fmt.Errorf("can't delete title column '%s'", schema.Name)
+	}
+	// only the column is changed, so that concurrent changes
+	// of other columns are not lost
+	return schemaOp(collection, CommandSet, []string{colID}, nil), nil
+}
+
+// AddColumn adds a column (property) with a given name and type
+// (e.g. ColumnTypeNumber) to a collection (database). opts can be nil
+// for types that don't need them. Returns id of the new column
+func (c *Client) AddColumn(collectionID string, name string, colType string, opts *ColumnOptions) (string, error) {
+	return c.AddColumnCtx(context.Background(), collectionID, name, colType, opts)
+}
+
+// AddColumnCtx is like AddColumn but can be cancelled with ctx
+func (c *Client) AddColumnCtx(ctx context.Context, collectionID string, name string, colType string, opts *ColumnOptions) (string, error) {
+	collection, err := c.getCollection(ctx, collectionID)
+	if err != nil {
+		return "", err
+	}
+	op, colID, err := addColumnOp(collection, name, colType, opts, c.collectionLoader(ctx))
+	if err != nil {
+		return "", err
+	}
+	if err = c.CommitTransactionCtx(ctx, NewTransaction().Add(op)); err != nil {
+		return "", err
+	}
+	return colID, nil
+}
+
+// RenameColumn changes the name of a column of a collection (database)
+func (c *Client) RenameColumn(collectionID string, columnName string, newName string) error {
+	return c.RenameColumnCtx(context.Background(), collectionID, columnName, newName)
+}
+
+// RenameColumnCtx is like RenameColumn but can be cancelled with ctx
+func (c *Client) RenameColumnCtx(ctx context.Context, collectionID string, columnName string, newName string) error {
+	collection, err := c.getCollection(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	colID, _, err := collection.findColumn(columnName)
+	if err != nil {
+		return err
+	}
+	if newName == "" {
+		return errors.New("column name is empty")
+	}
+	for _, id := range collection.columnIDsNamed(newName) {
+		if id != colID {
+			return fmt.Errorf("collection '%s' already has column '%s'", collection.ID, newName)
+		}
+	}
+	op := schemaOp(collection, CommandSet, []string{colID, "name"}, newName)
+	return c.CommitTransactionCtx(ctx, NewTransaction().Add(op))
+}
+
+// ChangeColumnType changes the type of a column of a collection (database).
+// When changing between select and multi-select and opts.Options is not
+// given, existing options are preserved. Values of the column in rows
+// are not converted
+func (c *Client) ChangeColumnType(collectionID string, columnName string, colType string, opts *ColumnOptions) error {
+	return c.ChangeColumnTypeCtx(context.Background(), collectionID, columnName, colType, opts)
+}
+
+// ChangeColumnTypeCtx is like ChangeColumnType but can be cancelled with ctx
+func (c *Client) ChangeColumnTypeCtx(ctx context.Context, collectionID string, columnName string, colType string, opts *ColumnOptions) error {
+	collection, err := c.getCollection(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	op, err := changeColumnTypeOp(collection, columnName, colType, opts, c.collectionLoader(ctx))
+	if err != nil {
+		return err
+	}
+	return c.CommitTransactionCtx(ctx, NewTransaction().Add(op))
+}
+
+// DeleteColumn deletes a column of a collection (database).
+// The title column can't be deleted
+func (c *Client) DeleteColumn(collectionID string, columnName string) error {
+	return c.DeleteColumnCtx(context.Background(), collectionID, columnName)
+}
+
+// DeleteColumnCtx is like DeleteColumn but can be cancelled with ctx
+func (c *Client) DeleteColumnCtx(ctx context.Context, collectionID string, columnName string) error {
+	collection, err := c.getCollection(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	op, err := deleteColumnOp(collection, columnName)
+	if err != nil {
+		return err
+	}
+	return c.CommitTransactionCtx(ctx, NewTransaction().Add(op))
+}
+
+// SetSelectOptions sets options of ColumnTypeSelect or ColumnTypeMultiSelect
+// column of a collection (database), in a given order. Existing options
+// keep their colors, options not in values are removed
+func (c *Client) SetSelectOptions(collectionID string, columnName string, values []string) error {
+	return c.SetSelectOptionsCtx(context.Background(), collectionID, columnName, values)
+}
+
+// SetSelectOptionsCtx is like SetSelectOptions but can be cancelled with ctx
+func (c *Client) SetSelectOptionsCtx(ctx context.Context, collectionID string, columnName string, values []string) error {
+	collection, err := c.getCollection(ctx, collectionID)
+	if err != nil {
+		return err
+	}
+	colID, schema, err := collection.findColumn(columnName)
+	if err != nil {
+		return err
+	}
+	if schema.Type != ColumnTypeSelect && schema.Type != ColumnTypeMultiSelect {
+		return fmt.Errorf("column '%s' is not a select or multi-select", schema.Name)
+	}
+	options, err := mergeColumnOptions(schema.Options, values)
+	if err != nil {
+		return err
+	}
+	op := schemaOp(collection, CommandSet, []string{colID, "options"}, options)
+	return c.CommitTransactionCtx(ctx, NewTransaction().Add(op))
+}
@@ -0,0 +1,153 @@
+package notionapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+func TestAddColumnOp(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	collection.Schema["rltn"].CollectionID = testBlockID(60)
+	related := &Collection{
+		ID: testBlockID(60),
+		Schema: map[string]*ColumnSchema{
+			"title": {Name: "Name", Type: ColumnTypeTitle},
+			"cost":  {Name: "Cost", Type: ColumnTypeNumber},
+		},
+	}
+	load := func(collectionID string) (*Collection, error) {
+		if collectionID != related.ID {
+			return nil, fmt.Errorf("no collection '%s'", collectionID)
+		}
+		return related, nil
+	}
+	op, colID, err := addColumnOp(collection, "Size", ColumnTypeSelect, &ColumnOptions{Options: []string{"S", "M"}}, load)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(colID))
+	assert.Nil(t, collection.Schema[colID])
+	assert.Equal(t, collection.ID, op.ID)
+	assert.Equal(t, TableCollection, op.Table)
+	assert.Equal(t, CommandSet, op.Command)
+	assert.Equal(t, []string{"schema", colID}, op.Path)
+	args := jsonRoundTrip(t, op.Args).(map[string]interface{})
+	assert.Equal(t, "Size", args["name"])
+	assert.Equal(t, "select", args["type"])
+	options := args["options"].([]interface{})
+	assert.Equal(t, 2, len(options))
+	assert.Equal(t, "M", options[1].(map[string]interface{})["value"])
+	assert.Equal(t, ColorGray, options[1].(map[string]interface{})["color"])
+
+	op, _, err = addColumnOp(collection, "Total", ColumnTypeRollup, &ColumnOptions{
+		RelationProperty: "Related", TargetProperty: "Cost", Aggregation: "sum",
+	}, load)
+	assert.NoError(t, err)
+	exp := map[string]interface{}{
+		"name": "Total", "type": "rollup", "relation_property": "rltn",
+		"target_property": "cost", "target_property_type": "number", "aggregation": "sum",
+	}
+	assert.Equal(t, exp, jsonRoundTrip(t, op.Args))
+
+	invalid := []struct {
+		name    string
+		colType string
+		opts    *ColumnOptions
+	}{
+		{"", ColumnTypeText, nil},
+		{"Price", ColumnTypeText, nil},
+		{"Other", ColumnTypeTitle, nil},
+		{"Other", "bogus", nil},
+		{"Other", ColumnTypeRelation, nil},
+		{"Other", ColumnTypeFormula, nil},
+		{"Other", ColumnTypeSelect, &ColumnOptions{Options: []string{"a", "a"}}},
+		{"Other", ColumnTypeRollup, &ColumnOptions{RelationProperty: "Price", TargetProperty: "x", Aggregation: "sum"}},
+		{"Other", ColumnTypeRollup, &ColumnOptions{RelationProperty: "Related", TargetProperty: "Missing", Aggregation: "sum"}},
+	}
+	for _, tc := range invalid {
+		_, _, err = addColumnOp(collection, tc.name, tc.colType, tc.opts, load)
+		assert.Error(t, err)
+	}
+}
+
+func TestChangeColumnTypeOp(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+
+	// options are preserved
+	op, err := changeColumnTypeOp(collection, "Status", ColumnTypeMultiSelect, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"schema", "slct"}, op.Path)
+	args := op.Args.(map[string]interface{})
+	assert.Equal(t, ColumnTypeMultiSelect, args["type"])
+	assert.Equal(t, collection.Schema["slct"].Options, args["options"])
+
+	op, err = changeColumnTypeOp(collection, "Status", ColumnTypeNumber, &ColumnOptions{NumberFormat: "dollar"}, nil)
+	assert.NoError(t, err)
+	exp := map[string]interface{}{"name": "Status", "type": "number", "number_format": "dollar"}
+	assert.Equal(t, exp, jsonRoundTrip(t, op.Args))
+
+	_, err = changeColumnTypeOp(collection, "Name", ColumnTypeText, nil, nil)
+	assert.Error(t, err)
+	_, err = changeColumnTypeOp(collection, "Missing", ColumnTypeText, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestDeleteColumnOp(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	op, err := deleteColumnOp(collection, "Status")
+	assert.NoError(t, err)
+	assert.Equal(t, CommandSet, op.Command)
+	assert.Equal(t, []string{"schema", "slct"}, op.Path)
+	assert.Nil(t, op.Args)
+
+	_, err = deleteColumnOp(collection, "Name")
+	assert.Error(t, err)
+}
+
+func TestSetSelectOptions(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	var submitted []*Operation
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			if strings.Contains(uri, "/api/v3/syncRecordValues") {
+				rsp := map[string]interface{}{
+					"recordMap": map[string]interface{}{
+						"collection": map[string]interface{}{
+							collection.ID: map[string]interface{}{
+								"role":  "editor",
+								"value": map[string]interface{}{"id": collection.ID, "schema": collection.Schema},
+							},
+						},
+					},
+				}
+				return jsonit.Marshal(rsp)
+			}
+			var req submitTransactionRequest
+			err := jsonit.Unmarshal(body, &req)
+			submitted = append(submitted, req.Operations...)
+			return []byte("{}"), err
+		},
+	}
+	err := c.SetSelectOptions(collection.ID, "Status", []string{"Done", "Blocked"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(submitted))
+	assert.Equal(t, []string{"schema", "slct", "options"}, submitted[0].Path)
+	options := submitted[0].Args.([]interface{})
+	assert.Equal(t, 2, len(options))
+	// existing option keeps its id
+	assert.Equal(t, "o2", options[0].(map[string]interface{})["id"])
+	assert.Equal(t, "Blocked", options[1].(map[string]interface{})["value"])
+
+	err = c.RenameColumn(collection.ID, "Price", "Cost")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"schema", "nmbr", "name"}, submitted[1].Path)
+	assert.Equal(t, "Cost", submitted[1].Args)
+
+	err = c.RenameColumn(collection.ID, "Price", "Status")
+	assert.Error(t, err)
+	err = c.SetSelectOptions(collection.ID, "Price", []string{"x"})
+	assert.Error(t, err)
+}
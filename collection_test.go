@@ -1,7 +1,12 @@
 package notionapi
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/kjk/common/assert"
@@ -40,3 +45,230 @@ func TestCollection(t *testing.T) {
 	// assert.NoError(t, err)
 	// assert.Len(t, rows, 10)
 }
+
+func fakeRowBlock(id string) map[string]interface{} {
+	return map[string]interface{}{
+		"role": "reader",
+		"value": map[string]interface{}{
+			"id":           id,
+			"type":         "page",
+			"alive":        true,
+			"parent_table": "collection",
+			"properties": map[string]interface{}{
+				"title": []interface{}{[]interface{}{"row " + id}},
+			},
+		},
+	}
+}
+
+// fakeCollectionServer simulates a collection with nRows rows where
+// queryCollection returns ids of all rows but only first nReturned rows
+func fakeCollectionServer(nRows int, nReturned int, nRequested *int) func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+	var ids []string
+	for i := 0; i < nRows; i++ {
+		ids = append(ids, fmt.Sprintf("00000000-0000-0000-0000-%012d", i))
+	}
+	return func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+		blocks := map[string]interface{}{}
+		var rsp map[string]interface{}
+		switch {
+		case strings.Contains(uri, "/api/v3/queryCollection"):
+			for _, id := range ids[:nReturned] {
+				blocks[id] = fakeRowBlock(id)
+			}
+			rsp = map[string]interface{}{
+				"result": map[string]interface{}{
+					"sizeHint": nRows,
+					"reducerResults": map[string]interface{}{
+						"collection_group_results": map[string]interface{}{
+							"blockIds": ids,
+							"hasMore":  false,
+						},
+					},
+				},
+			}
+		case strings.Contains(uri, "/api/v3/syncRecordValues"):
+			var req syncRecordRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				return nil, err
+			}
+			for _, r := range req.Requests {
+				blocks[r.Pointer.ID] = fakeRowBlock(r.Pointer.ID)
+			}
+			*nRequested += len(req.Requests)
+		default:
+			return nil, fmt.Errorf("unexpected request to '%s'", uri)
+		}
+		if rsp == nil {
+			rsp = map[string]interface{}{}
+		}
+		rsp["recordMap"] = map[string]interface{}{
+			"block": blocks,
+		}
+		return json.Marshal(rsp)
+	}
+}
+
+func newFakeTableView() *TableView {
+	return &TableView{
+		Page:           &Page{ID: "00000000-0000-0000-0000-000000000001"},
+		Collection:     &Collection{ID: "collection"},
+		CollectionView: &CollectionView{ID: "collection-view"},
+	}
+}
+
+func TestFetchAllTableRows(t *testing.T) {
+	nRequested := 0
+	c := &Client{
+		AuthToken:        "token",
+		httpPostOverride: fakeCollectionServer(1200, 100, &nRequested),
+	}
+	tv, err := c.FetchAllTableRows(newFakeTableView())
+	assert.NoError(t, err)
+	assert.Equal(t, 1200, len(tv.RowIds))
+	assert.Equal(t, 1200, len(tv.Rows))
+	assert.Equal(t, 1100, nRequested)
+	for i, tr := range tv.Rows {
+		assert.Equal(t, tv.RowIds[i], tr.Page.ID)
+		assert.Equal(t, tv, tr.TableView)
+	}
+
+	// e.g. collection failed to load
+	tv = newFakeTableView()
+	tv.Collection = nil
+	_, err = c.FetchAllTableRows(tv)
+	assert.Error(t, err)
+}
+
+func TestForEachTableRow(t *testing.T) {
+	nRequested := 0
+	c := &Client{
+		AuthToken:        "token",
+		httpPostOverride: fakeCollectionServer(30, 10, &nRequested),
+	}
+	tv := newFakeTableView()
+	seen := map[string]bool{}
+	err := c.ForEachTableRow(tv, func(tr *TableRow) error {
+		seen[tr.Page.ID] = true
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 30, len(seen))
+	assert.Equal(t, 10, len(tv.Rows))
+
+	errStop := fmt.Errorf("stop")
+	n := 0
+	err = c.ForEachTableRow(tv, func(tr *TableRow) error {
+		n++
+		if n == 15 {
+			return errStop
+		}
+		return nil
+	})
+	assert.Equal(t, errStop, err)
+	assert.Equal(t, 15, n)
+}
+
+func TestTableRowColumns(t *testing.T) {
+	for _, auth := range []string{"token", ""} {
+		nRequested := 0
+		c := &Client{
+			AuthToken:        auth,
+			httpPostOverride: fakeCollectionServer(30, 10, &nRequested),
+		}
+		tv := newFakeTableView()
+		tv.SpaceShortId = "123"
+		tv.Collection.Schema = map[string]*ColumnSchema{
+			"title": {Name: "Name", Type: ColumnTypeTitle},
+			"abcd":  {Name: "Notes", Type: ColumnTypeText},
+			"hidn":  {Name: "Hidden", Type: ColumnTypeText},
+		}
+		// title column is missing in table properties
+		tv.CollectionView.Format = &FormatTable{
+			TableProperties: []*TableProperty{
+				{Property: "abcd", Visible: true},
+				{Property: "hidn", Visible: false},
+			},
+		}
+		tv, err := c.FetchAllTableRows(tv)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, tv.ColumnCount())
+		assert.Equal(t, "Name", tv.Columns[0].Name())
+		assert.Equal(t, 0, tv.Columns[0].Index)
+		assert.Equal(t, "Notes", tv.Columns[1].Name())
+		assert.Equal(t, 1, tv.Columns[1].Index)
+
+		assert.Equal(t, 30, tv.RowCount())
+		for i, tr := range tv.Rows {
+			assert.Equal(t, tv, tr.TableView)
+			assert.Equal(t, 2, len(tr.Columns))
+			assert.Equal(t, "row "+tv.RowIds[i], TextSpansToString(tv.CellContent(i, 0)))
+			assert.Equal(t, 0, len(tv.CellContent(i, 1)))
+		}
+	}
+}
+
+func TestFetchTableRowsByIds(t *testing.T) {
+	collectionID := testBlockID(50)
+	nCollectionRequests := 0
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			assert.True(t, strings.Contains(uri, "/api/v3/syncRecordValuesSpaceInitial"))
+			assert.Equal(t, "123", headers[0].Get("x-notion-space-short-id"))
+			var req syncRecordRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				return nil, err
+			}
+			records := map[string]interface{}{}
+			table := req.Requests[0].Pointer.Table
+			for _, r := range req.Requests {
+				if table == TableCollection {
+					records[r.Pointer.ID] = map[string]interface{}{
+						"role": "reader",
+						"value": map[string]interface{}{
+							"id": r.Pointer.ID,
+							"schema": map[string]interface{}{
+								"title": map[string]interface{}{"name": "Name", "type": "title"},
+								"abcd":  map[string]interface{}{"name": "Notes", "type": "text"},
+							},
+						},
+					}
+					continue
+				}
+				row := fakeRowBlock(r.Pointer.ID)
+				row["value"].(map[string]interface{})["parent_id"] = collectionID
+				if r.Pointer.ID == testBlockID(3) {
+					// not a row
+					row["value"].(map[string]interface{})["parent_table"] = TableBlock
+				}
+				records[r.Pointer.ID] = row
+			}
+			if table == TableCollection {
+				nCollectionRequests++
+			}
+			return json.Marshal(map[string]interface{}{
+				"recordMap": map[string]interface{}{table: records},
+			})
+		},
+	}
+	ids := []string{testBlockID(1), testBlockID(2), testBlockID(3)}
+	rows, err := c.FetchTableRowsByIds("123", ids)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, nCollectionRequests)
+	assert.Equal(t, 3, len(rows))
+	assert.Nil(t, rows[2].TableView)
+	assert.Nil(t, rows[2].Columns)
+	rows = rows[:2]
+	tv := rows[0].TableView
+	assert.NotNil(t, tv)
+	assert.Equal(t, tv, rows[1].TableView)
+	assert.Equal(t, collectionID, tv.Collection.ID)
+	// all columns, title first
+	assert.Equal(t, 2, tv.ColumnCount())
+	assert.Equal(t, "Name", tv.Columns[0].Name())
+	assert.Equal(t, "Notes", tv.Columns[1].Name())
+	for i, tr := range rows {
+		assert.Equal(t, 2, len(tr.Columns))
+		assert.Equal(t, "row "+ids[i], TextSpansToString(tr.Columns[0]))
+	}
+}
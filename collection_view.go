@@ -0,0 +1,533 @@
+package notionapi
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// types of covers of cards in board and gallery views
+const (
+	// ViewCoverPageCover shows cover image of the page
+	ViewCoverPageCover = "page_cover"
+	// ViewCoverPageContent shows first image in the page
+	ViewCoverPageContent = "page_content"
+	// ViewCoverProperty shows first image of ColumnTypeFile column
+	ViewCoverProperty = "property"
+)
+
+var collectionViewTypes = map[string]bool{
+	CollectionViewTypeTable:    true,
+	CollectionViewTypeList:     true,
+	CollectionViewTypeBoard:    true,
+	CollectionViewTypeCalendar: true,
+	CollectionViewTypeGallery:  true,
+	CollectionViewTypeTimeline: true,
+}
+
+// ViewCover describes what is shown as a cover of cards in board
+// and gallery views
+type ViewCover struct {
+	// ViewCoverPageCover, ViewCoverPageContent or ViewCoverProperty
+	Type string `json:"type"`
+	// for ViewCoverProperty, id of ColumnTypeFile column
+	Property string `json:"property,omitempty"`
+}
+
+// ViewGroupBy describes a column board view is grouped by
+type ViewGroupBy struct {
+	// type of the column e.g. ColumnTypeSelect
+	Type     string `json:"type"`
+	Property string `json:"property"`
+}
+
+// FormatBoard describes format of CollectionViewTypeBoard view
+type FormatBoard struct {
+	BoardProperties []*TableProperty `json:"board_properties"`
+	BoardColumnsBy  *ViewGroupBy     `json:"board_columns_by"`
+	BoardCover      *ViewCover       `json:"board_cover"`
+	// "small", "medium", "large"
+	BoardCoverSize string `json:"board_cover_size"`
+	// "contain", "cover"
+	BoardCoverAspect string `json:"board_cover_aspect"`
+}
+
+// FormatList describes format of CollectionViewTypeList view
+type FormatList struct {
+	ListProperties []*TableProperty `json:"list_properties"`
+}
+
+// FormatCalendar describes format of CollectionViewTypeCalendar view.
+// The date column is in Query.CalendarBy
+type FormatCalendar struct {
+	CalendarProperties []*TableProperty `json:"calendar_properties"`
+}
+
+// FormatGallery describes format of CollectionViewTypeGallery view
+type FormatGallery struct {
+	GalleryProperties []*TableProperty `json:"gallery_properties"`
+	GalleryCover      *ViewCover       `json:"gallery_cover"`
+	// "small", "medium", "large"
+	GalleryCoverSize string `json:"gallery_cover_size"`
+	// "contain", "cover"
+	GalleryCoverAspect string `json:"gallery_cover_aspect"`
+}
+
+// FormatTimeline describes format of CollectionViewTypeTimeline view.
+// The date column is in Query.TimelineBy
+type FormatTimeline struct {
+	TimelineProperties      []*TableProperty `json:"timeline_properties"`
+	TimelineShowTable       bool             `json:"timeline_show_table"`
+	TimelineTableProperties []*TableProperty `json:"timeline_table_properties"`
+	TimelinePreference      struct {
+		// "day", "week", "month" etc.
+		ZoomLevel string `json:"zoomLevel"`
+	} `json:"timeline_preference"`
+}
+
+// unmarshalFormat decodes "format" of a view of expectedType into v.
+// Returns false if view is of a different type, has no format or
+// format can't be decoded
+func (cv *CollectionView) unmarshalFormat(expectedType string, v interface{}) bool {
+	if cv.Type != expectedType {
+		return false
+	}
+	formatRaw := jsonGetMap(cv.RawJSON, "format")
+	if len(formatRaw) == 0 {
+		return false
+	}
+	err := jsonUnmarshalFromMap(formatRaw, v)
+	if err != nil {
+		Logf("CollectionView.unmarshalFormat: invalid format of view '%s': %s\n", cv.ID, err)
+		return false
+	}
+	return true
+}
+
+// FormatBoard returns decoded format of CollectionViewTypeBoard view
+// or nil if the view is of a different type or format is invalid
+func (cv *CollectionView) FormatBoard() *FormatBoard {
+	var format FormatBoard
+	if ok := cv.unmarshalFormat(CollectionViewTypeBoard, &format); !ok {
+		return nil
+	}
+	return &format
+}
+
+// FormatList returns decoded format of CollectionViewTypeList view
+// or nil if the view is of a different type or format is invalid
+func (cv *CollectionView) FormatList() *FormatList {
+	var format FormatList
+	if ok := cv.unmarshalFormat(CollectionViewTypeList, &format); !ok {
+		return nil
+	}
+	return &format
+}
+
+// FormatCalendar returns decoded format of CollectionViewTypeCalendar view
+// or nil if the view is of a different type or format is invalid
+func (cv *CollectionView) FormatCalendar() *FormatCalendar {
+	var format FormatCalendar
+	if ok := cv.unmarshalFormat(CollectionViewTypeCalendar, &format); !ok {
+		return nil
+	}
+	return &format
+}
+
+// FormatGallery returns decoded format of CollectionViewTypeGallery view
+// or nil if the view is of a different type or format is invalid
+func (cv *CollectionView) FormatGallery() *FormatGallery {
+	var format FormatGallery
+	if ok := cv.unmarshalFormat(CollectionViewTypeGallery, &format); !ok {
+		return nil
+	}
+	return &format
+}
+
+// FormatTimeline returns decoded format of CollectionViewTypeTimeline view
+// or nil if the view is of a different type or format is invalid
+func (cv *CollectionView) FormatTimeline() *FormatTimeline {
+	var format FormatTimeline
+	if ok := cv.unmarshalFormat(CollectionViewTypeTimeline, &format); !ok {
+		return nil
+	}
+	return &format
+}
+
+// CollectionViewSpec describes a collection view for CreateCollectionView
+// and UpdateCollectionView. Columns are given by names (or ids).
+// In UpdateCollectionView, fields that are not set are not changed
+type CollectionViewSpec struct {
+	// CollectionViewTypeTable etc.
+	Type string
+	Name string
+
+	// visible columns, in order. Other columns are hidden.
+	// If nil, all columns are visible
+	Properties []string
+	// widths of columns in table and timeline views
+	Widths map[string]int
+
+	Filter Filter
+	Sort   []SortSpec
+
+	// for CollectionViewTypeBoard, a select, multi-select or
+	// person column cards are grouped by
+	GroupBy string
+	// for CollectionViewTypeCalendar and CollectionViewTypeTimeline,
+	// a date column
+	DateProperty string
+
+	// for CollectionViewTypeBoard and CollectionViewTypeGallery.
+	// Property of ViewCoverProperty cover is a name (or id) of a column
+	Cover *ViewCover
+	// "small", "medium", "large"
+	CoverSize string
+	// "contain", "cover"
+	CoverAspect string
+}
+
+// viewProperties returns <type>_properties of a view with visible columns
+// first, in a given order
+func viewProperties(collection *Collection, visible []string, widths map[string]int) ([]*TableProperty, error) {
+	var res []*TableProperty
+	seen := map[string]bool{}
+	add := func(colID string, isVisible bool) {
+		seen[colID] = true
+		res = append(res, &TableProperty{
+			Property: colID,
+			Visible:  isVisible,
+		})
+	}
+	for _, name := range visible {
+		colID, _, err := collection.findColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[colID] {
+			add(colID, true)
+		}
+	}
+	// remaining columns, title first and then by name
+	var rest []string
+	for colID := range collection.Schema {
+		if !seen[colID] {
+			rest = append(rest, colID)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		si, sj := collection.Schema[rest[i]], collection.Schema[rest[j]]
+		if (si.Type == ColumnTypeTitle) != (sj.Type == ColumnTypeTitle) {
+			return si.Type == ColumnTypeTitle
+		}
+		return si.Name < sj.Name
+	})
+	for _, colID := range rest {
+		add(colID, visible == nil)
+	}
+
+	for name, width := range widths {
+		colID, _, err := collection.findColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range res {
+			if p.Property == colID {
+				p.Width = width
+			}
+		}
+	}
+	return res, nil
+}
+
+// findColumnOfType returns id and schema of a column that must be of one of types
+func findColumnOfType(collection *Collection, name string, what string, types ...string) (string, *ColumnSchema, error) {
+	colID, schema, err := collection.findColumn(name)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, t := range types {
+		if schema.Type == t {
+			return colID, schema, nil
+		}
+	}
+	return "", nil, fmt.Errorf("column '%s' of type '%s' can't be %s", schema.Name, schema.Type, what)
+}
+
+// formatArgs returns format of a view of viewType. If isNew is false,
+// only fields set in s are returned
+func (s *CollectionViewSpec) formatArgs(collection *Collection, viewType string, isNew bool) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+	if isNew || s.Properties != nil || s.Widths != nil {
+		props, err := viewProperties(collection, s.Properties, s.Widths)
+		if err != nil {
+			return nil, err
+		}
+		res[viewType+"_properties"] = props
+	}
+
+	if viewType == CollectionViewTypeBoard && s.GroupBy != "" {
+		colID, schema, err := findColumnOfType(collection, s.GroupBy, "grouped by", ColumnTypeSelect, ColumnTypeMultiSelect, ColumnTypePerson)
+		if err != nil {
+			return nil, err
+		}
+		res["board_columns_by"] = &ViewGroupBy{
+			Type:     schema.Type,
+			Property: colID,
+		}
+	}
+
+	if viewType == CollectionViewTypeBoard || viewType == CollectionViewTypeGallery {
+		if s.Cover != nil {
+			cover := *s.Cover
+			switch cover.Type {
+			case ViewCoverPageCover, ViewCoverPageContent:
+				cover.Property = ""
+			case ViewCoverProperty:
+				colID, _, err := findColumnOfType(collection, cover.Property, "a cover", ColumnTypeFile)
+				if err != nil {
+					return nil, err
+				}
+				cover.Property = colID
+			default:
+				return nil, fmt.Errorf("invalid cover type '%s'", cover.Type)
+			}
+			res[viewType+"_cover"] = &cover
+		}
+		if s.CoverSize != "" {
+			res[viewType+"_cover_size"] = s.CoverSize
+		}
+		if s.CoverAspect != "" {
+			res[viewType+"_cover_aspect"] = s.CoverAspect
+		}
+	}
+	return res, nil
+}
+
+// queryArgs returns query2 of a view of viewType. If isNew is false,
+// only fields set in s are returned
+func (s *CollectionViewSpec) queryArgs(collection *Collection, viewType string, isNew bool) (map[string]interface{}, error) {
+	res := map[string]interface{}{}
+	if s.Filter != nil || s.Sort != nil {
+		query, err := BuildQuery(collection, s.Filter, s.Sort)
+		if err != nil {
+			return nil, err
+		}
+		if s.Filter != nil {
+			res["filter"] = query.Filter
+		}
+		if s.Sort != nil {
+			sorts := query.Sort
+			if sorts == nil {
+				sorts = []QuerySort{}
+			}
+			res["sort"] = sorts
+		}
+	}
+
+	switch viewType {
+	case CollectionViewTypeBoard:
+		if s.GroupBy == "" {
+			if isNew {
+				return nil, fmt.Errorf("board view needs GroupBy")
+			}
+			break
+		}
+		colID, _, err := findColumnOfType(collection, s.GroupBy, "grouped by", ColumnTypeSelect, ColumnTypeMultiSelect, ColumnTypePerson)
+		if err != nil {
+			return nil, err
+		}
+		res["group_by"] = colID
+	case CollectionViewTypeCalendar, CollectionViewTypeTimeline:
+		if s.DateProperty == "" {
+			if isNew {
+				return nil, fmt.Errorf("%s view needs DateProperty", viewType)
+			}
+			break
+		}
+		colID, _, err := findColumnOfType(collection, s.DateProperty, "a date of "+viewType, ColumnTypeDate, ColumnTypeCreatedTime, ColumnTypeLastEditedTime)
+		if err != nil {
+			return nil, err
+		}
+		res[viewType+"_by"] = colID
+	}
+	return res, nil
+}
+
+// createViewOps returns operations that create a view with viewID of
+// collection shown in block (BlockCollectionView or BlockCollectionViewPage)
+func createViewOps(block *Block, collection *Collection, viewID string, spec *CollectionViewSpec) ([]*Operation, error) {
+	if block.Type != BlockCollectionView && block.Type != BlockCollectionViewPage {
+		return nil, fmt.Errorf("block '%s' of type '%s' can't have collection views", block.ID, block.Type)
+	}
+	viewType := spec.Type
+	if !collectionViewTypes[viewType] {
+		return nil, fmt.Errorf("invalid collection view type '%s'", viewType)
+	}
+	format, err := spec.formatArgs(collection, viewType, true)
+	if err != nil {
+		return nil, err
+	}
+	query, err := spec.queryArgs(collection, viewType, true)
+	if err != nil {
+		return nil, err
+	}
+	name := spec.Name
+	if name == "" {
+		name = strings.ToUpper(viewType[:1]) + viewType[1:] + " view"
+	}
+	args := map[string]interface{}{
+		"id":           viewID,
+		"version":      1,
+		"type":         viewType,
+		"name":         name,
+		"format":       format,
+		"query2":       query,
+		"parent_id":    block.ID,
+		"parent_table": TableBlock,
+		"alive":        true,
+		"space_id":     block.SpaceID,
+	}
+	op := &Operation{
+		ID:      viewID,
+		Table:   TableCollectionView,
+		Path:    []string{},
+		Command: CommandSet,
+		Args:    args,
+	}
+	return []*Operation{op, block.ListAfterViewIDsOp(viewID)}, nil
+}
+
+// updateViewOps returns operations that change view according to spec
+func updateViewOps(view *CollectionView, collection *Collection, spec *CollectionViewSpec) ([]*Operation, error) {
+	viewType := view.Type
+	viewOp := func(path []string, args map[string]interface{}) *Operation {
+		return &Operation{
+			ID:      view.ID,
+			Table:   TableCollectionView,
+			Path:    path,
+			Command: CommandUpdate,
+			Args:    args,
+		}
+	}
+
+	top := map[string]interface{}{}
+	if spec.Name != "" {
+		top["name"] = spec.Name
+	}
+	typeChanged := spec.Type != "" && spec.Type != view.Type
+	if typeChanged {
+		if !collectionViewTypes[spec.Type] {
+			return nil, fmt.Errorf("invalid collection view type '%s'", spec.Type)
+		}
+		viewType = spec.Type
+		top["type"] = viewType
+	}
+	// a view that changes type needs everything that a new view needs
+	format, err := spec.formatArgs(collection, viewType, typeChanged)
+	if err != nil {
+		return nil, err
+	}
+	query, err := spec.queryArgs(collection, viewType, typeChanged)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []*Operation
+	if len(top) > 0 {
+		ops = append(ops, viewOp([]string{}, top))
+	}
+	if len(format) > 0 {
+		ops = append(ops, viewOp([]string{"format"}, format))
+	}
+	if len(query) > 0 {
+		ops = append(ops, viewOp([]string{"query2"}, query))
+	}
+	return ops, nil
+}
+
+// viewCollectionID returns id of the collection shown in viewID view
+// of block. If viewID is "", it's the block's collection
+func viewCollectionID(block *Block, viewID string) string {
+	collectionID := block.FixCollectionID()
+	// support for multiple collections on one page, like in loadTableViews
+	collectionIDs := block.CollectionIDs()
+	for i, id := range block.ViewIDs {
+		if id == viewID && i < len(collectionIDs) {
+			collectionID = collectionIDs[i]
+		}
+	}
+	return collectionID
+}
+
+// viewBlockCollection returns collection shown in viewID view of block
+// (BlockCollectionView or BlockCollectionViewPage)
+func (c *Client) viewBlockCollection(ctx context.Context, block *Block, viewID string) (*Collection, error) {
+	collectionID := viewCollectionID(block, viewID)
+	if collectionID == "" {
+		return nil, fmt.Errorf("block '%s' doesn't have a collection", block.ID)
+	}
+	return c.getCollection(ctx, collectionID)
+}
+
+// CreateCollectionView creates a new view of a collection shown in blockID
+// (BlockCollectionView or BlockCollectionViewPage block).
+// Returns id of the new view
+func (c *Client) CreateCollectionView(blockID string, spec *CollectionViewSpec) (string, error) {
+	return c.CreateCollectionViewCtx(context.Background(), blockID, spec)
+}
+
+// CreateCollectionViewCtx is like CreateCollectionView but can be cancelled with ctx
+func (c *Client) CreateCollectionViewCtx(ctx context.Context, blockID string, spec *CollectionViewSpec) (string, error) {
+	block, err := c.getBlock(ctx, ToDashID(blockID))
+	if err != nil {
+		return "", err
+	}
+	collection, err := c.viewBlockCollection(ctx, block, "")
+	if err != nil {
+		return "", err
+	}
+	viewID := uuid.New().String()
+	ops, err := createViewOps(block, collection, viewID, spec)
+	if err != nil {
+		return "", err
+	}
+	if err = c.CommitTransactionCtx(ctx, NewTransaction().Add(ops...)); err != nil {
+		return "", err
+	}
+	return viewID, nil
+}
+
+// UpdateCollectionView changes a collection view. Only fields set in spec
+// are changed
+func (c *Client) UpdateCollectionView(viewID string, spec *CollectionViewSpec) error {
+	return c.UpdateCollectionViewCtx(context.Background(), viewID, spec)
+}
+
+// UpdateCollectionViewCtx is like UpdateCollectionView but can be cancelled with ctx
+func (c *Client) UpdateCollectionViewCtx(ctx context.Context, viewID string, spec *CollectionViewSpec) error {
+	views, err := c.GetCollectionViewRecordsCtx(ctx, []string{viewID})
+	if err != nil {
+		return err
+	}
+	view := views[0]
+	if view == nil {
+		return fmt.Errorf("collection view '%s' doesn't exist or is not accessible", viewID)
+	}
+	block, err := c.getBlock(ctx, view.ParentID)
+	if err != nil {
+		return err
+	}
+	collection, err := c.viewBlockCollection(ctx, block, view.ID)
+	if err != nil {
+		return err
+	}
+	ops, err := updateViewOps(view, collection, spec)
+	if err != nil || len(ops) == 0 {
+		return err
+	}
+	return c.CommitTransactionCtx(ctx, NewTransaction().Add(ops...))
+}
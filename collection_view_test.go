@@ -0,0 +1,220 @@
+package notionapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+func newTestViewBlock() *Block {
+	return &Block{
+		ID:           testBlockID(70),
+		Type:         BlockCollectionView,
+		SpaceID:      testSpaceID,
+		CollectionID: testBlockID(50),
+	}
+}
+
+func TestViewProperties(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	props, err := viewProperties(collection, []string{"Price", "Name"}, map[string]int{"Name": 200})
+	assert.NoError(t, err)
+	assert.Equal(t, len(collection.Schema), len(props))
+	assert.Equal(t, &TableProperty{Property: "nmbr", Visible: true}, props[0])
+	assert.Equal(t, &TableProperty{Property: "title", Visible: true, Width: 200}, props[1])
+	// hidden columns are sorted by name
+	assert.Equal(t, &TableProperty{Property: "AbCd", Visible: false}, props[2])
+
+	props, err = viewProperties(collection, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "title", props[0].Property)
+	for _, p := range props {
+		assert.True(t, p.Visible)
+	}
+
+	_, err = viewProperties(collection, []string{"Missing"}, nil)
+	assert.Error(t, err)
+}
+
+func TestCreateViewOps(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	collection.Schema["file"] = &ColumnSchema{Name: "Image", Type: ColumnTypeFile}
+	block := newTestViewBlock()
+	spec := &CollectionViewSpec{
+		Type:       CollectionViewTypeBoard,
+		Properties: []string{"Name", "Price"},
+		GroupBy:    "Status",
+		Cover:      &ViewCover{Type: ViewCoverProperty, Property: "Image"},
+		CoverSize:  "large",
+		Filter:     Prop("Done").IsChecked(),
+		Sort:       []SortSpec{Desc("Price")},
+	}
+	ops, err := createViewOps(block, collection, testBlockID(71), spec)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ops))
+
+	op := ops[0]
+	assert.Equal(t, TableCollectionView, op.Table)
+	assert.Equal(t, testBlockID(71), op.ID)
+	args := jsonRoundTrip(t, op.Args).(map[string]interface{})
+	assert.Equal(t, "Board view", args["name"])
+	assert.Equal(t, block.ID, args["parent_id"])
+	assert.Equal(t, testSpaceID, args["space_id"])
+	format := args["format"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "select", "property": "slct"}, format["board_columns_by"])
+	assert.Equal(t, map[string]interface{}{"type": "property", "property": "file"}, format["board_cover"])
+	assert.Equal(t, "large", format["board_cover_size"])
+	assert.Equal(t, len(collection.Schema), len(format["board_properties"].([]interface{})))
+	query := args["query2"].(map[string]interface{})
+	assert.Equal(t, "slct", query["group_by"])
+	assert.Equal(t, "and", query["filter"].(map[string]interface{})["operator"])
+	assert.Equal(t, 1, len(query["sort"].([]interface{})))
+
+	assert.Equal(t, block.ID, ops[1].ID)
+	assert.Equal(t, []string{"view_ids"}, ops[1].Path)
+	assert.Equal(t, CommandListAfter, ops[1].Command)
+
+	ops, err = createViewOps(block, collection, testBlockID(71), &CollectionViewSpec{
+		Type:         CollectionViewTypeCalendar,
+		Name:         "Due dates",
+		DateProperty: "Due",
+	})
+	assert.NoError(t, err)
+	args = ops[0].Args.(map[string]interface{})
+	assert.Equal(t, "Due dates", args["name"])
+	assert.Equal(t, map[string]interface{}{"calendar_by": "date"}, args["query2"])
+
+	invalid := []*CollectionViewSpec{
+		{Type: "bogus"},
+		{Type: CollectionViewTypeBoard},
+		{Type: CollectionViewTypeBoard, GroupBy: "Price"},
+		{Type: CollectionViewTypeCalendar},
+		{Type: CollectionViewTypeTimeline, DateProperty: "Name"},
+		{Type: CollectionViewTypeGallery, Cover: &ViewCover{Type: ViewCoverProperty, Property: "Name"}},
+		{Type: CollectionViewTypeTable, Properties: []string{"Missing"}},
+	}
+	for _, spec := range invalid {
+		_, err = createViewOps(block, collection, testBlockID(71), spec)
+		assert.Error(t, err)
+	}
+	_, err = createViewOps(&Block{Type: BlockPage}, collection, testBlockID(71), &CollectionViewSpec{Type: CollectionViewTypeTable})
+	assert.Error(t, err)
+}
+
+func TestUpdateViewOps(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	view := &CollectionView{ID: testBlockID(71), Type: CollectionViewTypeTable}
+
+	ops, err := updateViewOps(view, collection, &CollectionViewSpec{Name: "Renamed"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(ops))
+	assert.Equal(t, CommandUpdate, ops[0].Command)
+	assert.Equal(t, map[string]interface{}{"name": "Renamed"}, ops[0].Args)
+
+	ops, err = updateViewOps(view, collection, &CollectionViewSpec{Widths: map[string]int{"Name": 300}, Sort: []SortSpec{}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ops))
+	assert.Equal(t, []string{"format"}, ops[0].Path)
+	assert.NotNil(t, ops[0].Args.(map[string]interface{})["table_properties"])
+	assert.Equal(t, []string{"query2"}, ops[1].Path)
+	assert.Equal(t, map[string]interface{}{"sort": []QuerySort{}}, ops[1].Args)
+
+	ops, err = updateViewOps(view, collection, &CollectionViewSpec{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(ops))
+
+	// changing type needs the same settings as a new view
+	_, err = updateViewOps(view, collection, &CollectionViewSpec{Type: CollectionViewTypeTimeline})
+	assert.Error(t, err)
+	ops, err = updateViewOps(view, collection, &CollectionViewSpec{Type: CollectionViewTypeTimeline, DateProperty: "Due"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(ops))
+	assert.Equal(t, CollectionViewTypeTimeline, ops[0].Args.(map[string]interface{})["type"])
+	assert.NotNil(t, ops[1].Args.(map[string]interface{})["timeline_properties"])
+	assert.Equal(t, map[string]interface{}{"timeline_by": "date"}, ops[2].Args)
+}
+
+func TestViewCollectionID(t *testing.T) {
+	block := newTestViewBlock()
+	assert.Equal(t, testBlockID(50), viewCollectionID(block, ""))
+
+	block.CollectionID = ""
+	block.Format.CollectionPointer.ID = testBlockID(51)
+	assert.Equal(t, testBlockID(51), viewCollectionID(block, ""))
+
+	// each view can show a different collection
+	block.ViewIDs = []string{testBlockID(71), testBlockID(72)}
+	block.Format.CollectionPointers = []CollectionPointer{{ID: testBlockID(51)}, {ID: testBlockID(52)}}
+	assert.Equal(t, testBlockID(52), viewCollectionID(block, testBlockID(72)))
+	assert.Equal(t, testBlockID(51), viewCollectionID(block, testBlockID(71)))
+}
+
+func TestCollectionViewFormat(t *testing.T) {
+	js := `{
+	"id": "view",
+	"type": "gallery",
+	"format": {
+		"gallery_cover": {"type": "page_content"},
+		"gallery_cover_size": "medium",
+		"gallery_properties": [{"property": "title", "visible": true}]
+	},
+	"query2": {"sort": []}
+}`
+	var rec Record
+	rec.Value = []byte(js)
+	assert.NoError(t, parseRecord(TableCollectionView, &rec))
+	cv := rec.CollectionView
+	format := cv.FormatGallery()
+	assert.NotNil(t, format)
+	assert.Equal(t, ViewCoverPageContent, format.GalleryCover.Type)
+	assert.Equal(t, "medium", format.GalleryCoverSize)
+	assert.Equal(t, 1, len(format.GalleryProperties))
+	assert.Nil(t, cv.FormatBoard())
+	assert.Nil(t, cv.FormatTimeline())
+
+	// malformed format doesn't panic
+	cv.RawJSON["format"] = map[string]interface{}{"gallery_properties": "bad"}
+	assert.Nil(t, cv.FormatGallery())
+}
+
+func TestCreateCollectionView(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	block := newTestViewBlock()
+	var submitted []*Operation
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			if strings.Contains(uri, "/api/v3/syncRecordValues") {
+				var req syncRecordRequest
+				assert.NoError(t, jsonit.Unmarshal(body, &req))
+				records := map[string]interface{}{}
+				switch req.Requests[0].Pointer.Table {
+				case TableBlock:
+					records["block"] = map[string]interface{}{
+						block.ID: map[string]interface{}{"role": "editor", "value": block},
+					}
+				case TableCollection:
+					records["collection"] = map[string]interface{}{
+						collection.ID: map[string]interface{}{
+							"role":  "editor",
+							"value": map[string]interface{}{"id": collection.ID, "schema": collection.Schema},
+						},
+					}
+				}
+				return jsonit.Marshal(map[string]interface{}{"recordMap": records})
+			}
+			var req submitTransactionRequest
+			err := jsonit.Unmarshal(body, &req)
+			submitted = append(submitted, req.Operations...)
+			return []byte("{}"), err
+		},
+	}
+	id, err := c.CreateCollectionView(block.ID, &CollectionViewSpec{Type: CollectionViewTypeList})
+	assert.NoError(t, err)
+	// view, view_ids and last edited time of the block
+	assert.Equal(t, 3, len(submitted))
+	assert.Equal(t, id, submitted[0].ID)
+	assert.Equal(t, map[string]interface{}{"id": id}, submitted[1].Args)
+}
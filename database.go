@@ -0,0 +1,217 @@
+package notionapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ColumnDef describes a column of a database created with CreateDatabase
+type ColumnDef struct {
+	Name string
+	// ColumnTypeTitle, ColumnTypeText etc.
+	Type string
+	// type-specific settings, can be nil for types that don't need them
+	Options *ColumnOptions
+}
+
+// newDatabaseCollection returns a collection (not yet created) shown
+// in blockID with a given schema. If schema doesn't have a title column,
+// "Name" title column is added. load is used to resolve rollup columns
+func newDatabaseCollection(collectionID string, blockID string, spaceID string, title string, schema []ColumnDef, load collectionLoader) (*Collection, error) {
+	name, err := TextSpansToRaw(plainText(title))
+	if err != nil {
+		return nil, err
+	}
+	collection := &Collection{
+		ID:          collectionID,
+		SpaceId:     &spaceID,
+		Version:     1,
+		Name:        name,
+		Schema:      map[string]*ColumnSchema{},
+		ParentID:    blockID,
+		ParentTable: TableBlock,
+		Alive:       true,
+	}
+	hasTitle := false
+	for _, def := range schema {
+		if def.Name == "" {
+			return nil, errors.New("column name is empty")
+		}
+		if collection.hasColumn(def.Name) {
+			return nil, fmt.Errorf("duplicate column '%s'", def.Name)
+		}
+		var colID string
+		switch {
+		case def.Type == ColumnTypeTitle:
+			if hasTitle {
+				return nil, errors.New("database can only have one title column")
+			}
+			hasTitle = true
+			colID = "title"
+		case creatableColumnTypes[def.Type]:
+			colID = newColumnID(collection)
+		default:
+			return nil, fmt.Errorf("can't create a column of type '%s'", def.Type)
+		}
+		colSchema := &ColumnSchema{
+			Name: def.Name,
+			Type: def.Type,
+		}
+		if err := applyColumnOptions(collection, colSchema, def.Options, load); err != nil {
+			return nil, err
+		}
+		collection.Schema[colID] = colSchema
+	}
+	if !hasTitle {
+		if collection.hasColumn("Name") {
+			return nil, errors.New("database needs a title column")
+		}
+		collection.Schema["title"] = &ColumnSchema{Name: "Name", Type: ColumnTypeTitle}
+	}
+
+	rawSchema := map[string]interface{}{}
+	for colID, s := range collection.Schema {
+		rawSchema[colID] = columnSchemaToRaw(s)
+	}
+	collection.RawJSON = map[string]interface{}{
+		"id":           collection.ID,
+		"version":      collection.Version,
+		"name":         collection.Name,
+		"schema":       rawSchema,
+		"parent_id":    collection.ParentID,
+		"parent_table": collection.ParentTable,
+		"alive":        true,
+		"space_id":     spaceID,
+	}
+	return collection, nil
+}
+
+// addDatabaseOps adds to tx operations that create a database (a block,
+// its collection and a table view) as the last child of parent page.
+// Returns the table view of the new, empty database
+func addDatabaseOps(tx *Transaction, parent *Block, title string, schema []ColumnDef, inline bool, load collectionLoader) (*TableView, error) {
+	if parent.Type != BlockPage {
+		return nil, fmt.Errorf("block '%s' of type '%s' is not a page", parent.ID, parent.Type)
+	}
+	blockType := BlockCollectionViewPage
+	if inline {
+		blockType = BlockCollectionView
+	}
+	block := &Block{
+		ID:           uuid.New().String(),
+		Type:         blockType,
+		SpaceID:      parent.SpaceID,
+		CollectionID: uuid.New().String(),
+	}
+	collection, err := newDatabaseCollection(block.CollectionID, block.ID, parent.SpaceID, title, schema, load)
+	if err != nil {
+		return nil, err
+	}
+
+	// columns are shown in the order of schema, title first
+	columns := []string{"title"}
+	for _, def := range schema {
+		if def.Type != ColumnTypeTitle {
+			columns = append(columns, def.Name)
+		}
+	}
+	viewSpec := &CollectionViewSpec{
+		Type:       CollectionViewTypeTable,
+		Properties: columns,
+	}
+	viewID := uuid.New().String()
+	viewOps, err := createViewOps(block, collection, viewID, viewSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	blockArgs := map[string]interface{}{
+		"id":               block.ID,
+		"version":          1,
+		"alive":            true,
+		"type":             blockType,
+		"parent_id":        parent.ID,
+		"parent_table":     TableBlock,
+		"space_id":         parent.SpaceID,
+		"collection_id":    collection.ID,
+		"created_time":     tx.now,
+		"last_edited_time": tx.now,
+	}
+	ops := []*Operation{
+		block.buildOp(CommandSet, []string{}, blockArgs),
+		parent.ListAfterContentOp(block.ID, ""),
+		{
+			ID:      collection.ID,
+			Table:   TableCollection,
+			Path:    []string{},
+			Command: CommandSet,
+			Args:    collection.RawJSON,
+		},
+	}
+	tx.Add(append(ops, viewOps...)...)
+
+	viewArgs := viewOps[0].Args.(map[string]interface{})
+	format := viewArgs["format"].(map[string]interface{})
+	view := &CollectionView{
+		ID:      viewID,
+		Version: 1,
+		Type:    CollectionViewTypeTable,
+		Format: &FormatTable{
+			TableProperties: format["table_properties"].([]*TableProperty),
+		},
+		Name:        viewArgs["name"].(string),
+		ParentID:    block.ID,
+		ParentTable: TableBlock,
+		Query:       &Query{},
+		Alive:       true,
+		SpaceID:     parent.SpaceID,
+		RawJSON:     viewArgs,
+	}
+	tv := &TableView{
+		CollectionView: view,
+		Collection:     collection,
+		SpaceId:        parent.SpaceID,
+	}
+	tv.buildColumns()
+	return tv, nil
+}
+
+// CreateDatabase creates a new database (a collection with a table view)
+// as the last child of parentPageID page. If inline is true, the database
+// is shown inside the page (BlockCollectionView), otherwise it's
+// a sub-page (BlockCollectionViewPage). Columns are in the order of schema.
+// If schema doesn't have ColumnTypeTitle column, "Name" title column is added.
+// The database is created in a single transaction, so either all of it
+// is created or nothing is.
+// Returns the table view of the new, empty database
+func (c *Client) CreateDatabase(parentPageID string, title string, schema []ColumnDef, inline bool) (*TableView, error) {
+	return c.CreateDatabaseCtx(context.Background(), parentPageID, title, schema, inline)
+}
+
+// CreateDatabaseCtx is like CreateDatabase but can be cancelled with ctx
+func (c *Client) CreateDatabaseCtx(ctx context.Context, parentPageID string, title string, schema []ColumnDef, inline bool) (*TableView, error) {
+	parent, err := c.getBlock(ctx, ToDashID(parentPageID))
+	if err != nil {
+		return nil, err
+	}
+	tx := NewTransaction()
+	tv, err := addDatabaseOps(tx, parent, title, schema, inline, c.collectionLoader(ctx))
+	if err != nil {
+		return nil, err
+	}
+	// a database split across transactions could be left half-created
+	reqs, err := tx.requests()
+	if err != nil {
+		return nil, err
+	}
+	if len(reqs) != 1 {
+		return nil, fmt.Errorf("database '%s' is too big for a single transaction", title)
+	}
+	if err = c.SubmitTransactionCtx(ctx, reqs[0].Operations); err != nil {
+		return nil, err
+	}
+	return tv, nil
+}
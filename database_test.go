@@ -0,0 +1,123 @@
+package notionapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+func TestAddDatabaseOps(t *testing.T) {
+	parent := &Block{ID: testBlockID(1), Type: BlockPage, SpaceID: testSpaceID}
+	schema := []ColumnDef{
+		{Name: "Price", Type: ColumnTypeNumber, Options: &ColumnOptions{NumberFormat: "dollar"}},
+		{Name: "Task", Type: ColumnTypeTitle},
+		{Name: "Status", Type: ColumnTypeSelect, Options: &ColumnOptions{Options: []string{"Todo", "Done"}}},
+	}
+	tx := NewTransaction()
+	tv, err := addDatabaseOps(tx, parent, "Tasks", schema, false, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Validate())
+
+	collection := tv.Collection
+	assert.Equal(t, "Tasks", collection.GetName())
+	assert.Equal(t, 3, len(collection.Schema))
+	assert.Equal(t, "Task", collection.Schema["title"].Name)
+	priceID, price, err := collection.findColumn("Price")
+	assert.NoError(t, err)
+	assert.Equal(t, "dollar", price.NumberFormat)
+
+	// columns are in the order of schema, title first
+	assert.Equal(t, 3, tv.ColumnCount())
+	assert.Equal(t, "title", tv.Columns[0].Property.Property)
+	assert.Equal(t, priceID, tv.Columns[1].Property.Property)
+	assert.Equal(t, "Status", tv.Columns[2].Name())
+	assert.Equal(t, 0, tv.RowCount())
+
+	// block, parent's content, collection, view, block's view_ids
+	// and last edited time of the parent
+	ops := tx.Operations()
+	assert.Equal(t, 6, len(ops))
+	block := ops[0]
+	args := block.Args.(map[string]interface{})
+	assert.Equal(t, BlockCollectionViewPage, args["type"])
+	assert.Equal(t, collection.ID, args["collection_id"])
+	assert.Equal(t, parent.ID, args["parent_id"])
+	assert.Equal(t, []string{"content"}, ops[1].Path)
+	assert.Equal(t, parent.ID, ops[1].ID)
+	assert.Equal(t, TableCollection, ops[2].Table)
+	assert.Equal(t, block.ID, ops[2].Args.(map[string]interface{})["parent_id"])
+	assert.Equal(t, TableCollectionView, ops[3].Table)
+	assert.Equal(t, tv.CollectionView.ID, ops[3].ID)
+	assert.Equal(t, []string{"view_ids"}, ops[4].Path)
+	assert.Equal(t, parent.ID, ops[5].ID)
+
+	tx = NewTransaction()
+	tv, err = addDatabaseOps(tx, parent, "Inline", []ColumnDef{{Name: "Notes", Type: ColumnTypeText}}, true, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, BlockCollectionView, tx.Operations()[0].Args.(map[string]interface{})["type"])
+	// title column is added
+	assert.Equal(t, "Name", tv.Collection.Schema["title"].Name)
+	assert.Equal(t, "Name", tv.Columns[0].Name())
+}
+
+func TestAddDatabaseOpsErrors(t *testing.T) {
+	parent := &Block{ID: testBlockID(1), Type: BlockPage, SpaceID: testSpaceID}
+	invalid := [][]ColumnDef{
+		{{Name: "", Type: ColumnTypeText}},
+		{{Name: "A", Type: ColumnTypeText}, {Name: "A", Type: ColumnTypeNumber}},
+		{{Name: "A", Type: ColumnTypeTitle}, {Name: "B", Type: ColumnTypeTitle}},
+		{{Name: "A", Type: "bogus"}},
+		{{Name: "Name", Type: ColumnTypeText}},
+		{{Name: "A", Type: ColumnTypeRelation}},
+	}
+	for _, schema := range invalid {
+		_, err := addDatabaseOps(NewTransaction(), parent, "db", schema, false, nil)
+		assert.Error(t, err)
+	}
+	_, err := addDatabaseOps(NewTransaction(), &Block{Type: BlockText}, "db", nil, false, nil)
+	assert.Error(t, err)
+}
+
+func TestCreateDatabase(t *testing.T) {
+	parent := &Block{ID: testBlockID(1), Type: BlockPage, SpaceID: testSpaceID, Alive: true}
+	nSubmits := 0
+	var submitted []*Operation
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			if strings.Contains(uri, "/api/v3/syncRecordValues") {
+				rsp := map[string]interface{}{
+					"recordMap": map[string]interface{}{
+						"block": map[string]interface{}{
+							parent.ID: map[string]interface{}{"role": "editor", "value": parent},
+						},
+					},
+				}
+				return jsonit.Marshal(rsp)
+			}
+			nSubmits++
+			var req submitTransactionRequest
+			err := jsonit.Unmarshal(body, &req)
+			submitted = append(submitted, req.Operations...)
+			return []byte("{}"), err
+		},
+	}
+	tv, err := c.CreateDatabase(ToNoDashID(parent.ID), "Tasks", []ColumnDef{{Name: "Done", Type: ColumnTypeCheckbox}}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, nSubmits)
+	assert.Equal(t, 6, len(submitted))
+	assert.Equal(t, testSpaceID, tv.SpaceId)
+	assert.Equal(t, 2, tv.ColumnCount())
+
+	// a database too big for one transaction is not created
+	var schema []ColumnDef
+	for i := 0; i < 4000; i++ {
+		schema = append(schema, ColumnDef{Name: fmt.Sprintf("Column %d", i), Type: ColumnTypeText})
+	}
+	_, err = c.CreateDatabase(ToNoDashID(parent.ID), "Big", schema, true)
+	assert.Error(t, err)
+	assert.Equal(t, 1, nSubmits)
+}
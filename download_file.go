@@ -2,6 +2,7 @@ package notionapi
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -21,7 +22,12 @@ type DownloadFileResponse struct {
 // DownloadURLStream downloads a given url with possibly authenticated client and returns a stream
 // The caller is responsible for closing the Response.Body when done
 func (c *Client) DownloadURLStream(uri string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", uri, nil)
+	return c.DownloadURLStreamCtx(context.Background(), uri)
+}
+
+// DownloadURLStreamCtx is like DownloadURLStream but can be cancelled with ctx
+func (c *Client) DownloadURLStreamCtx(ctx context.Context, uri string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -35,9 +41,10 @@ func (c *Client) DownloadURLStream(uri string) (*http.Response, error) {
 		return nil, err
 	}
 	if resp.StatusCode >= 400 {
+		d, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
 		//fmt.Printf("DownloadFile: httpClient.Do() for '%s' failed with '%s'\n", uri, resp.Status)
-		return nil, fmt.Errorf("http GET '%s' failed with status %s", uri, resp.Status)
+		return nil, newAPIError("GET", uri, resp, d)
 	}
 
 	return resp, nil
@@ -45,7 +52,12 @@ func (c *Client) DownloadURLStream(uri string) (*http.Response, error) {
 
 // DownloadURL downloads a given url with possibly authenticated client
 func (c *Client) DownloadURL(uri string) (*DownloadFileResponse, error) {
-	resp, err := c.DownloadURLStream(uri)
+	return c.DownloadURLCtx(context.Background(), uri)
+}
+
+// DownloadURLCtx is like DownloadURL but can be cancelled with ctx
+func (c *Client) DownloadURLCtx(ctx context.Context, uri string) (*DownloadFileResponse, error) {
+	resp, err := c.DownloadURLStreamCtx(ctx, uri)
 	if err != nil {
 		return nil, err
 	}
@@ -120,15 +132,20 @@ func maybeProxyImageURL(uri string, block *Block) string {
 // by a block with a given id and of a given block with a given
 // parent table (data present in Block)
 func (c *Client) DownloadFile(uri string, block *Block) (*DownloadFileResponse, error) {
+	return c.DownloadFileCtx(context.Background(), uri, block)
+}
+
+// DownloadFileCtx is like DownloadFile but can be cancelled with ctx
+func (c *Client) DownloadFileCtx(ctx context.Context, uri string, block *Block) (*DownloadFileResponse, error) {
 	// first try downloading proxied url
 	uri2 := maybeProxyImageURL(uri, block)
-	res, err := c.DownloadURL(uri2)
-	if err != nil && uri2 != uri {
+	res, err := c.DownloadURLCtx(ctx, uri2)
+	if err != nil && uri2 != uri && ctx.Err() == nil {
 		// otherwise just try your luck with original URL
-		res, err = c.DownloadURL(uri)
+		res, err = c.DownloadURLCtx(ctx, uri)
 	}
-	if err != nil {
-		rsp, err2 := c.GetSignedURLs([]string{uri}, block)
+	if err != nil && ctx.Err() == nil {
+		rsp, err2 := c.GetSignedURLsCtx(ctx, []string{uri}, block)
 		if err2 != nil {
 			return nil, err
 		}
@@ -136,7 +153,7 @@ func (c *Client) DownloadFile(uri string, block *Block) (*DownloadFileResponse,
 			return nil, err
 		}
 		uri3 := rsp.SignedURLS[0]
-		res, err = c.DownloadURL(uri3)
+		res, err = c.DownloadURLCtx(ctx, uri3)
 	}
 	return res, err
 }
@@ -144,11 +161,16 @@ func (c *Client) DownloadFile(uri string, block *Block) (*DownloadFileResponse,
 // DownloadFileStream downloads a file stored in Notion and returns a stream for streaming operations
 // The caller is responsible for closing the Response.Body when done
 func (c *Client) DownloadFileStream(uri string, block *Block) (*http.Response, error) {
+	return c.DownloadFileStreamCtx(context.Background(), uri, block)
+}
+
+// DownloadFileStreamCtx is like DownloadFileStream but can be cancelled with ctx
+func (c *Client) DownloadFileStreamCtx(ctx context.Context, uri string, block *Block) (*http.Response, error) {
 	// first try downloading proxied url
 	uri2 := maybeProxyImageURL(uri, block)
-	res, err := c.DownloadURLStream(uri2)
-	if err != nil {
-		rsp, err2 := c.GetSignedURLs([]string{uri}, block)
+	res, err := c.DownloadURLStreamCtx(ctx, uri2)
+	if err != nil && ctx.Err() == nil {
+		rsp, err2 := c.GetSignedURLsCtx(ctx, []string{uri}, block)
 		if err2 != nil {
 			return nil, err
 		}
@@ -156,7 +178,7 @@ func (c *Client) DownloadFileStream(uri string, block *Block) (*http.Response, e
 			return nil, err
 		}
 		uri3 := rsp.SignedURLS[0]
-		res, err = c.DownloadURLStream(uri3)
+		res, err = c.DownloadURLStreamCtx(ctx, uri3)
 	}
 	return res, err
 }
@@ -164,7 +186,12 @@ func (c *Client) DownloadFileStream(uri string, block *Block) (*http.Response, e
 // DownloadAttachmentStream downloads an attachment file stored in Notion and returns a stream for streaming operations
 // The caller is responsible for closing the Response.Body when done
 func (c *Client) DownloadAttachmentStream(uid string, block *Block) (*http.Response, error) {
-	return c.DownloadURLStream(c.GetAttachmentURL(uid, block))
+	return c.DownloadAttachmentStreamCtx(context.Background(), uid, block)
+}
+
+// DownloadAttachmentStreamCtx is like DownloadAttachmentStream but can be cancelled with ctx
+func (c *Client) DownloadAttachmentStreamCtx(ctx context.Context, uid string, block *Block) (*http.Response, error) {
+	return c.DownloadURLStreamCtx(ctx, c.GetAttachmentURL(uid, block))
 }
 
 // GetAttachmentURL returns the URL for an attachment file stored in Notion referenced by a block
@@ -0,0 +1,124 @@
+package notionapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// APIError is returned when Notion server responds with a non-200 status code
+type APIError struct {
+	// Method is http method of the request e.g. "POST"
+	Method string
+	// URI of the request
+	URI string
+	// StatusCode is http status code e.g. 401
+	StatusCode int
+	// Status is http status e.g. "401 Unauthorized"
+	Status string
+
+	// those are parsed from JSON body of the response (if it's JSON)
+	// e.g. "UnauthorizedError", "ValidationError"
+	ErrorID string
+	Name    string
+	Message string
+
+	// Body is raw body of the response
+	Body []byte
+	// RetryAfter is parsed from Retry-After header, if present
+	RetryAfter time.Duration
+}
+
+// apiErrorBody is JSON body of an error response. It's decoded separately
+// from APIError so that other fields of the body (e.g. "status") don't
+// overwrite fields of APIError
+type apiErrorBody struct {
+	ErrorID string `json:"errorId"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+func newAPIError(method string, uri string, rsp *http.Response, body []byte) *APIError {
+	res := &APIError{
+		Method:     method,
+		URI:        uri,
+		StatusCode: rsp.StatusCode,
+		Status:     rsp.Status,
+		Body:       body,
+		RetryAfter: parseRetryAfter(rsp.Header.Get("Retry-After")),
+	}
+	// body is not always json (e.g. for errors from s3 or proxies)
+	// so ignore errors
+	var errBody apiErrorBody
+	if jsonit.Valid(body) && jsonit.Unmarshal(body, &errBody) == nil {
+		res.ErrorID = errBody.ErrorID
+		res.Name = errBody.Name
+		res.Message = errBody.Message
+	}
+	return res
+}
+
+// Error returns error string
+func (e *APIError) Error() string {
+	s := fmt.Sprintf("http %s '%s' failed with status code %d", e.Method, e.URI, e.StatusCode)
+	if e.Name != "" || e.Message != "" {
+		s += fmt.Sprintf(" (%s: %s)", e.Name, e.Message)
+	}
+	return s
+}
+
+// Retryable returns true if the request might succeed if re-tried
+// i.e. it was rate-limited or failed due to a temporary server error
+func (e *APIError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// AsAPIError returns *APIError if err is (or wraps) an APIError
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+func isAPIErrorWithStatus(err error, statusCode int) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.StatusCode == statusCode
+}
+
+// IsUnauthorized returns true if err is APIError with 401 status code.
+// This usually means that Client.AuthToken (token_v2 cookie) has expired
+func IsUnauthorized(err error) bool {
+	return isAPIErrorWithStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden returns true if err is APIError with 403 status code
+func IsForbidden(err error) bool {
+	return isAPIErrorWithStatus(err, http.StatusForbidden)
+}
+
+// IsNotFound returns true if err is APIError with 404 status code
+func IsNotFound(err error) bool {
+	return isAPIErrorWithStatus(err, http.StatusNotFound)
+}
+
+// IsRateLimited returns true if err is APIError with 429 status code
+func IsRateLimited(err error) bool {
+	return isAPIErrorWithStatus(err, http.StatusTooManyRequests)
+}
+
+// IsRetryable returns true if err is APIError that is Retryable()
+func IsRetryable(err error) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.Retryable()
+}
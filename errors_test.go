@@ -0,0 +1,62 @@
+package notionapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+const unauthorizedErrorJSON = `{
+	"errorId": "3c6b6d4e-5c4b-4a5e-9b8a-4a1f1f1e1f1e",
+	"name": "UnauthorizedError",
+	"message": "Token was invalid or expired.",
+	"clientData": { "type": "login_try_again" },
+	"status": 401
+}`
+
+func TestAPIError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, unauthorizedErrorJSON)
+	}))
+	defer ts.Close()
+
+	c := &Client{}
+	_, err := c.doPostInternal(context.Background(), ts.URL, []byte("{}"))
+	assert.Error(t, err)
+	apiErr, ok := AsAPIError(err)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, "401 Unauthorized", apiErr.Status)
+	assert.Equal(t, "UnauthorizedError", apiErr.Name)
+	assert.Equal(t, "Token was invalid or expired.", apiErr.Message)
+	assert.Equal(t, "3c6b6d4e-5c4b-4a5e-9b8a-4a1f1f1e1f1e", apiErr.ErrorID)
+	assert.False(t, apiErr.Retryable())
+
+	wrapped := fmt.Errorf("wrapped: %w", err)
+	assert.True(t, IsUnauthorized(wrapped))
+	assert.False(t, IsForbidden(wrapped))
+	assert.False(t, IsRetryable(wrapped))
+}
+
+func TestAPIErrorRetryable(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		retryable  bool
+	}{
+		{http.StatusBadRequest, false},
+		{http.StatusNotFound, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+	}
+	for _, tc := range tests {
+		e := &APIError{StatusCode: tc.statusCode}
+		assert.Equal(t, tc.retryable, e.Retryable())
+	}
+}
@@ -1,6 +1,7 @@
 package notionapi
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
@@ -64,6 +65,12 @@ type getTasksRequest struct {
 // RequestPageExportURL executes a raw API call to enqueue an export of pages
 // and returns the URL to the exported data once the task is complete
 func (c *Client) RequestPageExportURL(id string, exportType string, recursive bool) (string, error) {
+	return c.RequestPageExportURLCtx(context.Background(), id, exportType, recursive)
+}
+
+// RequestPageExportURLCtx is like RequestPageExportURL but can be cancelled with ctx.
+// Cancelling ctx also stops polling for the status of the export task
+func (c *Client) RequestPageExportURLCtx(ctx context.Context, id string, exportType string, recursive bool) (string, error) {
 	id = ToDashID(id)
 	if !IsValidDashID(id) {
 		return "", fmt.Errorf("'%s' is not a valid notion id", id)
@@ -86,7 +93,7 @@ func (c *Client) RequestPageExportURL(id string, exportType string, recursive bo
 	var rsp enqueueTaskResponse
 	var err error
 	apiURL := "/api/v3/enqueueTask"
-	err = c.doNotionAPI(apiURL, req, &rsp, &rsp.RawJSON)
+	err = c.doNotionAPI(ctx, apiURL, req, &rsp, &rsp.RawJSON)
 	if err != nil {
 		return "", err
 	}
@@ -94,14 +101,16 @@ func (c *Client) RequestPageExportURL(id string, exportType string, recursive bo
 	var exportURL string
 	taskID := rsp.TaskID
 	for {
-		time.Sleep(250 * time.Millisecond)
+		if err := sleepCtx(ctx, 250*time.Millisecond); err != nil {
+			return "", err
+		}
 		req := getTasksRequest{
 			TaskIDS: []string{taskID},
 		}
 		var err error
 		var rsp getTasksExportPageResponse
 		apiURL = "/api/v3/getTasks"
-		err = c.doNotionAPI(apiURL, req, &rsp, nil)
+		err = c.doNotionAPI(ctx, apiURL, req, &rsp, nil)
 		if err != nil {
 			return "", err
 		}
@@ -110,7 +119,9 @@ func (c *Client) RequestPageExportURL(id string, exportType string, recursive bo
 			exportURL = status.ExportURL
 			break
 		}
-		time.Sleep(750 * time.Millisecond)
+		if err := sleepCtx(ctx, 750*time.Millisecond); err != nil {
+			return "", err
+		}
 	}
 
 	return exportURL, nil
@@ -118,12 +129,17 @@ func (c *Client) RequestPageExportURL(id string, exportType string, recursive bo
 
 // ExportPages exports a page as html or markdown, potentially recursively
 func (c *Client) ExportPages(id string, exportType string, recursive bool) ([]byte, error) {
-	exportURL, err := c.RequestPageExportURL(id, exportType, recursive)
+	return c.ExportPagesCtx(context.Background(), id, exportType, recursive)
+}
+
+// ExportPagesCtx is like ExportPages but can be cancelled with ctx
+func (c *Client) ExportPagesCtx(ctx context.Context, id string, exportType string, recursive bool) ([]byte, error) {
+	exportURL, err := c.RequestPageExportURLCtx(ctx, id, exportType, recursive)
 	if err != nil {
 		return nil, err
 	}
 
-	dlRsp, err := c.DownloadFile(exportURL, nil)
+	dlRsp, err := c.DownloadFileCtx(ctx, exportURL, nil)
 	if err != nil {
 		return nil, err
 	}
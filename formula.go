@@ -0,0 +1,1095 @@
+package notionapi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values of evaluated formulas (see ColumnFormula.Eval) are:
+// - float64 for numbers
+// - string for text
+// - bool for checkboxes
+// - time.Time for dates
+// - nil for empty values
+
+// maps operator symbols to names of operators used in formula tree
+var formulaOperators = map[string]string{
+	"+":   "add",
+	"-":   "subtract",
+	"*":   "multiply",
+	"/":   "divide",
+	"%":   "mod",
+	"^":   "pow",
+	"==":  "equal",
+	"!=":  "unequal",
+	">":   "larger",
+	">=":  "largerEq",
+	"<":   "smaller",
+	"<=":  "smallerEq",
+	"and": "and",
+	"or":  "or",
+	"not": "not",
+	"?":   "if",
+}
+
+// formulaEvaluator evaluates formulas against a collection row
+type formulaEvaluator struct {
+	row *TableRow
+	now time.Time
+	// ids of formula columns being evaluated, to detect cycles
+	inProgress map[string]bool
+}
+
+// EvalFormula evaluates a ColumnTypeFormula column with a given name (or id)
+// for this row. See ColumnFormula.Eval for the type of returned value
+func (r *TableRow) EvalFormula(columnName string) (interface{}, error) {
+	if r.TableView == nil || r.TableView.Collection == nil {
+		return nil, errors.New("row doesn't have a collection")
+	}
+	colID, schema, err := r.TableView.Collection.findColumn(columnName)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Type != ColumnTypeFormula || schema.Formula == nil {
+		return nil, fmt.Errorf("column '%s' is not a formula", schema.Name)
+	}
+	e := newFormulaEvaluator(r)
+	return e.evalColumn(colID, schema)
+}
+
+// Eval evaluates the formula for a given row. The value is float64, string,
+// bool, time.Time or nil (for empty values)
+func (f *ColumnFormula) Eval(row *TableRow) (interface{}, error) {
+	return newFormulaEvaluator(row).eval(f.root())
+}
+
+// FormatFormulaValue formats a value returned by ColumnFormula.Eval the
+// way format() function does it
+func FormatFormulaValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if v.Hour() == 0 && v.Minute() == 0 {
+			return formatDateMoment(v, "MMMM D, YYYY")
+		}
+		return formatDateMoment(v, "MMMM D, YYYY h:mm A")
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func newFormulaEvaluator(row *TableRow) *formulaEvaluator {
+	return &formulaEvaluator{
+		row:        row,
+		now:        time.Now(),
+		inProgress: map[string]bool{},
+	}
+}
+
+// root returns top-level node of the formula tree
+func (f *ColumnFormula) root() *FormulaArg {
+	n := &FormulaArg{
+		ResultType: f.ResultType,
+		Type:       f.Type,
+		Value:      f.Value,
+		ValueType:  f.ValueType,
+		ID:         f.ID,
+		Operator:   f.Operator,
+		Args:       f.Args,
+		Condition:  f.Condition,
+		True:       f.True,
+		False:      f.False,
+	}
+	if f.Name != "" {
+		name := f.Name
+		n.Name = &name
+	}
+	return n
+}
+
+func (n *FormulaArg) name() string {
+	if n.Name == nil {
+		return ""
+	}
+	return *n.Name
+}
+
+func (e *formulaEvaluator) evalColumn(colID string, schema *ColumnSchema) (interface{}, error) {
+	if e.inProgress[colID] {
+		return nil, fmt.Errorf("formula of column '%s' refers to itself", schema.Name)
+	}
+	e.inProgress[colID] = true
+	defer delete(e.inProgress, colID)
+	return e.eval(schema.Formula.root())
+}
+
+func (e *formulaEvaluator) eval(n *FormulaArg) (interface{}, error) {
+	v, err := e.evalNode(n)
+	// results of e.g. division by zero or sqrt of a negative number are
+	// empty, like in Notion. This also keeps them out of exported JSON,
+	// which can't represent them
+	if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+		return nil, err
+	}
+	return v, err
+}
+
+func (e *formulaEvaluator) evalNode(n *FormulaArg) (interface{}, error) {
+	if n == nil {
+		return nil, errors.New("missing formula argument")
+	}
+	switch n.Type {
+	case "constant":
+		return evalFormulaConstant(n)
+	case "symbol":
+		switch n.name() {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "e":
+			return math.E, nil
+		case "pi":
+			return math.Pi, nil
+		}
+		return nil, fmt.Errorf("unknown symbol '%s'", n.name())
+	case "property":
+		name := n.ID
+		if name == "" {
+			name = n.name()
+		}
+		return e.evalProperty(name)
+	case "conditional":
+		return e.evalIf(n.Condition, n.True, n.False)
+	case "operator", "function":
+		name := n.name()
+		if name == "" {
+			name = formulaOperators[n.Operator]
+		}
+		if name == "subtract" && len(n.Args) == 1 {
+			name = "unaryMinus"
+		}
+		return e.call(name, n.Args)
+	}
+	return nil, fmt.Errorf("unknown formula node type '%s'", n.Type)
+}
+
+func evalFormulaConstant(n *FormulaArg) (interface{}, error) {
+	if n.Value == nil {
+		return nil, nil
+	}
+	v := *n.Value
+	valueType := n.ResultType
+	if n.ValueType != nil {
+		valueType = *n.ValueType
+	}
+	switch valueType {
+	case "number":
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number constant '%s'", v)
+		}
+		return f, nil
+	case "boolean", "checkbox":
+		return v == "true", nil
+	}
+	return v, nil
+}
+
+// dateToTime converts a start of a Date to time.Time
+func dateToTime(d *Date) (time.Time, error) {
+	loc := time.UTC
+	if d.TimeZone != nil {
+		if l, err := time.LoadLocation(*d.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	if d.StartTime == "" {
+		return time.ParseInLocation("2006-01-02", d.StartDate, loc)
+	}
+	return time.ParseInLocation("2006-01-02 15:04", d.StartDate+" "+d.StartTime, loc)
+}
+
+func (e *formulaEvaluator) evalProperty(name string) (interface{}, error) {
+	if e.row.TableView == nil || e.row.TableView.Collection == nil {
+		return nil, errors.New("row doesn't have a collection")
+	}
+	colID, schema, err := e.row.TableView.Collection.findColumn(name)
+	if err != nil {
+		return nil, err
+	}
+	switch schema.Type {
+	case ColumnTypeFormula:
+		if schema.Formula == nil {
+			return nil, fmt.Errorf("column '%s' doesn't have a formula", schema.Name)
+		}
+		return e.evalColumn(colID, schema)
+	case ColumnTypeRollup:
+		v, err := e.row.Rollup(colID)
+		if err != nil {
+			return nil, err
+		}
+		// like Notion, original values are shown as comma-separated text
+		if values, ok := v.([]*CellValue); ok {
+			var parts []string
+			for _, cv := range values {
+				if s := cv.String(); s != "" {
+					parts = append(parts, s)
+				}
+			}
+			if len(parts) == 0 {
+				return nil, nil
+			}
+			return strings.Join(parts, ", "), nil
+		}
+		return v, nil
+	}
+	v, err := e.row.Get(colID)
+	if err != nil {
+		return nil, err
+	}
+	if v.IsEmpty() {
+		if schema.Type == ColumnTypeCheckbox {
+			return false, nil
+		}
+		return nil, nil
+	}
+	switch schema.Type {
+	case ColumnTypeNumber:
+		return v.AsFloat()
+	case ColumnTypeCheckbox:
+		return v.AsBool(), nil
+	case ColumnTypeDate, ColumnTypeCreatedTime, ColumnTypeLastEditedTime:
+		d := v.AsDate()
+		if d == nil {
+			return nil, nil
+		}
+		return dateToTime(d)
+	case ColumnTypePerson, ColumnTypeCreatedBy, ColumnTypeLastEditedBy:
+		return strings.Join(v.AsUserIDs(), ","), nil
+	case ColumnTypeRelation:
+		return strings.Join(v.AsPageIDs(), ","), nil
+	case ColumnTypeURL:
+		return v.AsURL(), nil
+	}
+	return v.Text(), nil
+}
+
+// hasTime returns true if a date value of n can have a time of day, i.e.
+// it's not derived only from dates without time. Used to format dates
+// returned by eval
+func (e *formulaEvaluator) hasTime(n *FormulaArg) bool {
+	return e.argHasTime(n, map[string]bool{})
+}
+
+// propertyHasTime is like hasTime for a column with a given name (or id)
+func (e *formulaEvaluator) propertyHasTime(name string) bool {
+	return e.columnHasTime(name, map[string]bool{})
+}
+
+// rollupHasTime is like hasTime for a rollup column: true if any
+// of the aggregated values has time
+func (e *formulaEvaluator) rollupHasTime(schema *ColumnSchema) bool {
+	return e.rollupTargetHasTime(schema, map[string]bool{})
+}
+
+// argHasTime implements hasTime. visited has columns already checked,
+// which stops cycles of formulas and rollups
+func (e *formulaEvaluator) argHasTime(n *FormulaArg, visited map[string]bool) bool {
+	if n == nil {
+		return false
+	}
+	switch n.Type {
+	case "property":
+		name := n.ID
+		if name == "" {
+			name = n.name()
+		}
+		return e.columnHasTime(name, visited)
+	case "conditional":
+		return e.argHasTime(n.True, visited) || e.argHasTime(n.False, visited)
+	case "operator", "function":
+		switch n.name() {
+		case "now", "fromTimestamp":
+			return true
+		case "dateAdd", "dateSubtract":
+			if len(n.Args) == 3 {
+				unit, _ := e.eval(&n.Args[2])
+				switch unit {
+				case "hours", "minutes", "seconds", "milliseconds":
+					return true
+				}
+			}
+		}
+		for i := range n.Args {
+			if e.argHasTime(&n.Args[i], visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (e *formulaEvaluator) columnHasTime(name string, visited map[string]bool) bool {
+	if e.row.TableView == nil || e.row.TableView.Collection == nil {
+		return false
+	}
+	collection := e.row.TableView.Collection
+	colID, schema, err := collection.findColumn(name)
+	if err != nil {
+		return false
+	}
+	switch schema.Type {
+	case ColumnTypeCreatedTime, ColumnTypeLastEditedTime:
+		return true
+	case ColumnTypeDate:
+		v, err := e.row.Get(colID)
+		if err != nil {
+			return false
+		}
+		d := v.AsDate()
+		return d != nil && (d.StartTime != "" || d.EndTime != "")
+	}
+	// formulas and rollups can refer to each other (also across
+	// collections), so they're checked only once
+	key := collection.ID + "/" + colID
+	if visited[key] {
+		return false
+	}
+	visited[key] = true
+	switch schema.Type {
+	case ColumnTypeFormula:
+		return schema.Formula != nil && e.argHasTime(schema.Formula.root(), visited)
+	case ColumnTypeRollup:
+		return e.rollupTargetHasTime(schema, visited)
+	}
+	return false
+}
+
+func (e *formulaEvaluator) rollupTargetHasTime(schema *ColumnSchema, visited map[string]bool) bool {
+	rows, targetID, _, err := e.row.rollupRows(schema)
+	if err != nil {
+		return false
+	}
+	for _, tr := range rows {
+		if newFormulaEvaluator(tr).columnHasTime(targetID, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *formulaEvaluator) evalIf(cond, ifTrue, ifFalse *FormulaArg) (interface{}, error) {
+	c, err := e.eval(cond)
+	if err != nil {
+		return nil, err
+	}
+	b, err := formulaBool(c)
+	if err != nil {
+		return nil, err
+	}
+	if b {
+		return e.eval(ifTrue)
+	}
+	return e.eval(ifFalse)
+}
+
+// evalAndOr evaluates "and" and "or", skipping arguments that don't
+// affect the result
+func (e *formulaEvaluator) evalAndOr(isAnd bool, args []FormulaArg) (interface{}, error) {
+	for i := range args {
+		v, err := e.eval(&args[i])
+		if err != nil {
+			return nil, err
+		}
+		b, err := formulaBool(v)
+		if err != nil {
+			return nil, err
+		}
+		if b != isAnd {
+			return b, nil
+		}
+	}
+	return isAnd, nil
+}
+
+func formulaBool(v interface{}) (bool, error) {
+	switch v := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	}
+	return false, fmt.Errorf("expected a boolean, got %s", formulaTypeName(v))
+}
+
+func formulaNumber(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		return v, nil
+	}
+	return 0, fmt.Errorf("expected a number, got %s", formulaTypeName(v))
+}
+
+func formulaString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	return "", fmt.Errorf("expected a text, got %s", formulaTypeName(v))
+}
+
+func formulaTime(v interface{}) (time.Time, error) {
+	if t, ok := v.(time.Time); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("expected a date, got %s", formulaTypeName(v))
+}
+
+func formulaTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "empty value"
+	case float64:
+		return "number"
+	case string:
+		return "text"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "date"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// formulaCompare compares values of the same type. An empty value is
+// treated as a zero value of the other type
+func formulaCompare(a, b interface{}) (int, error) {
+	if a == nil {
+		a, b = b, a
+		n, err := formulaCompare(a, b)
+		return -n, err
+	}
+	switch av := a.(type) {
+	case float64:
+		bv, err := formulaNumber(b)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case av < bv:
+			return -1, nil
+		case av > bv:
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		bv, err := formulaString(b)
+		if err != nil {
+			return 0, err
+		}
+		return strings.Compare(av, bv), nil
+	case bool:
+		bv, err := formulaBool(b)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case av == bv:
+			return 0, nil
+		case bv:
+			return -1, nil
+		}
+		return 1, nil
+	case time.Time:
+		if b == nil {
+			return 1, nil
+		}
+		bv, err := formulaTime(b)
+		if err != nil {
+			return 0, err
+		}
+		return av.Compare(bv), nil
+	}
+	// both are empty
+	return 0, nil
+}
+
+func formulaIsEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case time.Time:
+		return v.IsZero()
+	}
+	return false
+}
+
+// formulaToNumber implements toNumber()
+func formulaToNumber(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil
+		}
+		return f
+	case bool:
+		if v {
+			return 1.0
+		}
+		return 0.0
+	case time.Time:
+		return float64(v.UnixMilli())
+	}
+	return nil
+}
+
+// dateAdd adds n units (e.g. "days") to t
+func dateAdd(t time.Time, n float64, unit string) (time.Time, error) {
+	i := int(n)
+	switch unit {
+	case "years":
+		return t.AddDate(i, 0, 0), nil
+	case "quarters":
+		return t.AddDate(0, 3*i, 0), nil
+	case "months":
+		return t.AddDate(0, i, 0), nil
+	case "weeks":
+		return t.AddDate(0, 0, 7*i), nil
+	case "days":
+		return t.AddDate(0, 0, i), nil
+	case "hours":
+		return t.Add(time.Duration(n * float64(time.Hour))), nil
+	case "minutes":
+		return t.Add(time.Duration(n * float64(time.Minute))), nil
+	case "seconds":
+		return t.Add(time.Duration(n * float64(time.Second))), nil
+	case "milliseconds":
+		return t.Add(time.Duration(n * float64(time.Millisecond))), nil
+	}
+	return t, fmt.Errorf("unknown date unit '%s'", unit)
+}
+
+// dateBetween returns number of whole units between t1 and t2. It's
+// negative if t1 is before t2
+func dateBetween(t1, t2 time.Time, unit string) (float64, error) {
+	months := func() int {
+		n := (t1.Year()-t2.Year())*12 + int(t1.Month()-t2.Month())
+		if n > 0 && t2.AddDate(0, n, 0).After(t1) {
+			n--
+		} else if n < 0 && t2.AddDate(0, n, 0).Before(t1) {
+			n++
+		}
+		return n
+	}
+	var d time.Duration
+	switch unit {
+	case "years":
+		return float64(months() / 12), nil
+	case "quarters":
+		return float64(months() / 3), nil
+	case "months":
+		return float64(months()), nil
+	case "weeks":
+		d = 7 * 24 * time.Hour
+	case "days":
+		d = 24 * time.Hour
+	case "hours":
+		d = time.Hour
+	case "minutes":
+		d = time.Minute
+	case "seconds":
+		d = time.Second
+	case "milliseconds":
+		d = time.Millisecond
+	default:
+		return 0, fmt.Errorf("unknown date unit '%s'", unit)
+	}
+	return float64(t1.Sub(t2) / d), nil
+}
+
+// tokens of moment.js format used by formatDate(), longest first
+var momentTokens = []string{
+	"YYYY", "YY", "MMMM", "MMM", "MM", "M", "DD", "D", "dddd", "ddd",
+	"HH", "H", "hh", "h", "mm", "m", "ss", "s", "A", "a",
+}
+
+// formatDateMoment formats t using moment.js format (e.g. "MMM D, YYYY")
+// like formatDate() function
+func formatDateMoment(t time.Time, format string) string {
+	hour12 := t.Hour() % 12
+	if hour12 == 0 {
+		hour12 = 12
+	}
+	values := map[string]string{
+		"YYYY": t.Format("2006"),
+		"YY":   t.Format("06"),
+		"MMMM": t.Format("January"),
+		"MMM":  t.Format("Jan"),
+		"MM":   t.Format("01"),
+		"M":    strconv.Itoa(int(t.Month())),
+		"DD":   t.Format("02"),
+		"D":    strconv.Itoa(t.Day()),
+		"dddd": t.Format("Monday"),
+		"ddd":  t.Format("Mon"),
+		"HH":   t.Format("15"),
+		"H":    strconv.Itoa(t.Hour()),
+		"hh":   fmt.Sprintf("%02d", hour12),
+		"h":    strconv.Itoa(hour12),
+		"mm":   t.Format("04"),
+		"m":    strconv.Itoa(t.Minute()),
+		"ss":   t.Format("05"),
+		"s":    strconv.Itoa(t.Second()),
+		"A":    t.Format("PM"),
+		"a":    strings.ToLower(t.Format("PM")),
+	}
+	var sb strings.Builder
+	for len(format) > 0 {
+		// text in [] is not formatted
+		if format[0] == '[' {
+			if end := strings.IndexByte(format, ']'); end > 0 {
+				sb.WriteString(format[1:end])
+				format = format[end+1:]
+				continue
+			}
+		}
+		matched := false
+		for _, tok := range momentTokens {
+			if strings.HasPrefix(format, tok) {
+				sb.WriteString(values[tok])
+				format = format[len(tok):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			sb.WriteByte(format[0])
+			format = format[1:]
+		}
+	}
+	return sb.String()
+}
+
+// call evaluates an operator or a function
+func (e *formulaEvaluator) call(name string, argNodes []FormulaArg) (interface{}, error) {
+	// those don't evaluate all arguments
+	switch name {
+	case "if":
+		if len(argNodes) != 3 {
+			return nil, fmt.Errorf("if() expects 3 arguments, got %d", len(argNodes))
+		}
+		return e.evalIf(&argNodes[0], &argNodes[1], &argNodes[2])
+	case "and", "or":
+		return e.evalAndOr(name == "and", argNodes)
+	}
+
+	args := make([]interface{}, len(argNodes))
+	for i := range argNodes {
+		v, err := e.eval(&argNodes[i])
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	nArgs := func(min, max int) error {
+		if len(args) < min || (max >= 0 && len(args) > max) {
+			return fmt.Errorf("%s() called with %d arguments", name, len(args))
+		}
+		return nil
+	}
+	numbers := func() ([]float64, error) {
+		res := make([]float64, len(args))
+		for i, v := range args {
+			f, err := formulaNumber(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s(): %w", name, err)
+			}
+			res[i] = f
+		}
+		return res, nil
+	}
+	strs := func() ([]string, error) {
+		res := make([]string, len(args))
+		for i, v := range args {
+			s, err := formulaString(v)
+			if err != nil {
+				return nil, fmt.Errorf("%s(): %w", name, err)
+			}
+			res[i] = s
+		}
+		return res, nil
+	}
+
+	switch name {
+	case "add":
+		if err := nArgs(2, 2); err != nil {
+			return nil, err
+		}
+		_, isStr1 := args[0].(string)
+		_, isStr2 := args[1].(string)
+		if isStr1 || isStr2 {
+			s, err := strs()
+			if err != nil {
+				return nil, err
+			}
+			return s[0] + s[1], nil
+		}
+		n, err := numbers()
+		if err != nil {
+			return nil, err
+		}
+		return n[0] + n[1], nil
+
+	case "subtract", "multiply", "divide", "mod", "pow":
+		if err := nArgs(2, 2); err != nil {
+			return nil, err
+		}
+		n, err := numbers()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "subtract":
+			return n[0] - n[1], nil
+		case "multiply":
+			return n[0] * n[1], nil
+		case "divide":
+			return n[0] / n[1], nil
+		case "mod":
+			return math.Mod(n[0], n[1]), nil
+		}
+		return math.Pow(n[0], n[1]), nil
+
+	case "unaryMinus", "unaryPlus":
+		if err := nArgs(1, 1); err != nil {
+			return nil, err
+		}
+		if name == "unaryPlus" {
+			return formulaToNumber(args[0]), nil
+		}
+		n, err := numbers()
+		if err != nil {
+			return nil, err
+		}
+		return -n[0], nil
+
+	case "not":
+		if err := nArgs(1, 1); err != nil {
+			return nil, err
+		}
+		b, err := formulaBool(args[0])
+		return !b, err
+
+	case "equal", "unequal", "larger", "largerEq", "smaller", "smallerEq":
+		if err := nArgs(2, 2); err != nil {
+			return nil, err
+		}
+		c, err := formulaCompare(args[0], args[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %w", name, err)
+		}
+		switch name {
+		case "equal":
+			return c == 0, nil
+		case "unequal":
+			return c != 0, nil
+		case "larger":
+			return c > 0, nil
+		case "largerEq":
+			return c >= 0, nil
+		case "smaller":
+			return c < 0, nil
+		}
+		return c <= 0, nil
+
+	case "concat":
+		s, err := strs()
+		if err != nil {
+			return nil, err
+		}
+		return strings.Join(s, ""), nil
+
+	case "join":
+		if err := nArgs(1, -1); err != nil {
+			return nil, err
+		}
+		s, err := strs()
+		if err != nil {
+			return nil, err
+		}
+		return strings.Join(s[1:], s[0]), nil
+
+	case "slice":
+		if err := nArgs(2, 3); err != nil {
+			return nil, err
+		}
+		s, err := formulaString(args[0])
+		if err != nil {
+			return nil, err
+		}
+		runes := []rune(s)
+		clamp := func(v interface{}) (int, error) {
+			f, err := formulaNumber(v)
+			n := int(f)
+			if n < 0 {
+				n = 0
+			}
+			if n > len(runes) {
+				n = len(runes)
+			}
+			return n, err
+		}
+		start, err := clamp(args[1])
+		if err != nil {
+			return nil, err
+		}
+		end := len(runes)
+		if len(args) == 3 {
+			if end, err = clamp(args[2]); err != nil {
+				return nil, err
+			}
+		}
+		if end < start {
+			return "", nil
+		}
+		return string(runes[start:end]), nil
+
+	case "length":
+		if err := nArgs(1, 1); err != nil {
+			return nil, err
+		}
+		s, err := formulaString(args[0])
+		return float64(len([]rune(s))), err
+
+	case "format":
+		if err := nArgs(1, 1); err != nil {
+			return nil, err
+		}
+		return FormatFormulaValue(args[0]), nil
+
+	case "toNumber":
+		if err := nArgs(1, 1); err != nil {
+			return nil, err
+		}
+		return formulaToNumber(args[0]), nil
+
+	case "contains", "test":
+		if err := nArgs(2, 2); err != nil {
+			return nil, err
+		}
+		s, err := strs()
+		if err != nil {
+			return nil, err
+		}
+		if name == "contains" {
+			return strings.Contains(s[0], s[1]), nil
+		}
+		re, err := regexp.Compile(s[1])
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(s[0]), nil
+
+	case "replace", "replaceAll":
+		if err := nArgs(3, 3); err != nil {
+			return nil, err
+		}
+		s, err := strs()
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(s[1])
+		if err != nil {
+			return nil, err
+		}
+		if name == "replaceAll" {
+			return re.ReplaceAllString(s[0], s[2]), nil
+		}
+		loc := re.FindStringSubmatchIndex(s[0])
+		if loc == nil {
+			return s[0], nil
+		}
+		repl := re.ExpandString(nil, s[2], s[0], loc)
+		return s[0][:loc[0]] + string(repl) + s[0][loc[1]:], nil
+
+	case "empty":
+		if err := nArgs(1, 1); err != nil {
+			return nil, err
+		}
+		return formulaIsEmpty(args[0]), nil
+
+	case "abs", "cbrt", "ceil", "exp", "floor", "ln", "log10", "log2", "round", "sign", "sqrt":
+		if err := nArgs(1, 1); err != nil {
+			return nil, err
+		}
+		n, err := numbers()
+		if err != nil {
+			return nil, err
+		}
+		fns := map[string]func(float64) float64{
+			"abs":   math.Abs,
+			"cbrt":  math.Cbrt,
+			"ceil":  math.Ceil,
+			"exp":   math.Exp,
+			"floor": math.Floor,
+			"ln":    math.Log,
+			"log10": math.Log10,
+			"log2":  math.Log2,
+			"sqrt":  math.Sqrt,
+			// like Math.round() in JavaScript
+			"round": func(f float64) float64 { return math.Floor(f + 0.5) },
+			"sign": func(f float64) float64 {
+				switch {
+				case f > 0:
+					return 1
+				case f < 0:
+					return -1
+				}
+				return 0
+			},
+		}
+		return fns[name](n[0]), nil
+
+	case "max", "min":
+		if err := nArgs(1, -1); err != nil {
+			return nil, err
+		}
+		n, err := numbers()
+		if err != nil {
+			return nil, err
+		}
+		res := n[0]
+		for _, f := range n[1:] {
+			if name == "max" {
+				res = math.Max(res, f)
+			} else {
+				res = math.Min(res, f)
+			}
+		}
+		return res, nil
+
+	case "now":
+		return e.now, nil
+
+	case "timestamp":
+		if err := nArgs(1, 1); err != nil {
+			return nil, err
+		}
+		t, err := formulaTime(args[0])
+		return float64(t.UnixMilli()), err
+
+	case "fromTimestamp":
+		if err := nArgs(1, 1); err != nil {
+			return nil, err
+		}
+		n, err := numbers()
+		if err != nil {
+			return nil, err
+		}
+		return time.UnixMilli(int64(n[0])).UTC(), nil
+
+	case "dateAdd", "dateSubtract":
+		if err := nArgs(3, 3); err != nil {
+			return nil, err
+		}
+		t, err := formulaTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		n, err := formulaNumber(args[1])
+		if err != nil {
+			return nil, err
+		}
+		unit, err := formulaString(args[2])
+		if err != nil {
+			return nil, err
+		}
+		if name == "dateSubtract" {
+			n = -n
+		}
+		return dateAdd(t, n, unit)
+
+	case "dateBetween":
+		if err := nArgs(3, 3); err != nil {
+			return nil, err
+		}
+		t1, err := formulaTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		t2, err := formulaTime(args[1])
+		if err != nil {
+			return nil, err
+		}
+		unit, err := formulaString(args[2])
+		if err != nil {
+			return nil, err
+		}
+		return dateBetween(t1, t2, unit)
+
+	case "formatDate":
+		if err := nArgs(2, 2); err != nil {
+			return nil, err
+		}
+		t, err := formulaTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		format, err := formulaString(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return formatDateMoment(t, format), nil
+
+	case "minute", "hour", "day", "date", "month", "year":
+		if err := nArgs(1, 1); err != nil {
+			return nil, err
+		}
+		t, err := formulaTime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		parts := map[string]int{
+			"minute": t.Minute(),
+			"hour":   t.Hour(),
+			// 0 is Sunday
+			"day":  int(t.Weekday()),
+			"date": t.Day(),
+			// months are 0-based, like in JavaScript
+			"month": int(t.Month()) - 1,
+			"year":  t.Year(),
+		}
+		return float64(parts[name]), nil
+
+	case "id":
+		if e.row.Page == nil {
+			return nil, errors.New("row has no page")
+		}
+		return ToNoDashID(e.row.Page.ID), nil
+	}
+	return nil, fmt.Errorf("unsupported formula function '%s'", name)
+}
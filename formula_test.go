@@ -0,0 +1,233 @@
+package notionapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kjk/common/assert"
+)
+
+func fConst(valueType string, v string) FormulaArg {
+	return FormulaArg{Type: "constant", ValueType: &valueType, Value: &v}
+}
+
+func fNum(v string) FormulaArg {
+	return fConst("number", v)
+}
+
+func fStr(v string) FormulaArg {
+	return fConst("string", v)
+}
+
+func fProp(id string) FormulaArg {
+	return FormulaArg{Type: "property", ID: id}
+}
+
+func fFunc(name string, args ...FormulaArg) FormulaArg {
+	return FormulaArg{Type: "function", Name: &name, Args: args}
+}
+
+func fOp(op string, args ...FormulaArg) FormulaArg {
+	return FormulaArg{Type: "operator", Operator: op, Args: args}
+}
+
+func newFormulaTestRow() *TableRow {
+	row := newTestTableRow()
+	d := map[string]interface{}{"type": "datetime", "start_date": "2021-01-31", "start_time": "09:30"}
+	row.Page.Properties = map[string]interface{}{
+		"title": spans("Buy milk", ""),
+		"AbCd":  spans("Yes", ""),
+		"nmbr":  spans("12.5", ""),
+		"slct":  spans("Todo", ""),
+		"tags":  spans("go,rust", ""),
+		"date":  []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"d", d}}}},
+	}
+	return row
+}
+
+func TestFormulaEval(t *testing.T) {
+	row := newFormulaTestRow()
+	due := time.Date(2021, 1, 31, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		f   FormulaArg
+		exp interface{}
+	}{
+		{fNum("2"), 2.0},
+		{fProp("Price"), 12.5},
+		{fProp("Done"), true},
+		{fProp("Name"), "Buy milk"},
+		{fProp("Tags"), "go,rust"},
+		{fProp("Link"), nil},
+		{fProp("Due"), due},
+		{fOp("+", fNum("1"), fOp("*", fProp("Price"), fNum("2"))), 26.0},
+		{fOp("-", fProp("Price")), -12.5},
+		{fOp("+", fProp("Name"), fStr("!")), "Buy milk!"},
+		{fOp("%", fNum("7"), fNum("4")), 3.0},
+		{fOp("^", fNum("2"), fNum("10")), 1024.0},
+		{fOp(">=", fProp("Price"), fNum("12.5")), true},
+		{fOp("==", fProp("Status"), fStr("Done")), false},
+		{fOp("!=", fProp("Link"), fStr("")), false},
+		{fOp("and", fProp("Done"), fOp("not", fProp("Done"))), false},
+		{fOp("or", fProp("Done"), fFunc("missing")), true},
+		{fFunc("if", fProp("Done"), fStr("yes"), fFunc("missing")), "yes"},
+		{fOp("?", fOp("<", fProp("Price"), fNum("10")), fStr("cheap"), fStr("expensive")), "expensive"},
+		{fFunc("concat", fProp("Name"), fStr(" ("), fFunc("format", fProp("Price")), fStr(")")), "Buy milk (12.5)"},
+		{fFunc("join", fStr("-"), fStr("a"), fStr("b")), "a-b"},
+		{fFunc("slice", fProp("Name"), fNum("4")), "milk"},
+		{fFunc("length", fProp("Name")), 8.0},
+		{fFunc("toNumber", fStr("42")), 42.0},
+		{fFunc("replaceAll", fStr("a-b-c"), fStr("-"), fStr("+")), "a+b+c"},
+		{fFunc("replace", fStr("a-b-c"), fStr("-"), fStr("+")), "a+b-c"},
+		{fFunc("test", fProp("Name"), fStr("^Buy")), true},
+		{fFunc("empty", fProp("Link")), true},
+		{fFunc("round", fNum("2.5")), 3.0},
+		{fFunc("max", fNum("1"), fProp("Price"), fNum("3")), 12.5},
+		{fFunc("formatDate", fProp("Due"), fStr("YYYY-MM-DD h:mm A [at] dddd")), "2021-01-31 9:30 AM at Sunday"},
+		{fFunc("dateAdd", fProp("Due"), fNum("1"), fStr("months")), time.Date(2021, 3, 3, 9, 30, 0, 0, time.UTC)},
+		{fFunc("dateSubtract", fProp("Due"), fNum("2"), fStr("hours")), due.Add(-2 * time.Hour)},
+		{fFunc("dateBetween", fFunc("dateAdd", fProp("Due"), fNum("10"), fStr("days")), fProp("Due"), fStr("weeks")), 1.0},
+		{fFunc("dateBetween", fProp("Due"), fFunc("fromTimestamp", fNum("0")), fStr("years")), 51.0},
+		{fFunc("month", fProp("Due")), 0.0},
+		{fFunc("format", fProp("Due")), "January 31, 2021 9:30 AM"},
+		{fFunc("timestamp", fProp("Due")), float64(due.UnixMilli())},
+		// numbers that aren't finite are empty
+		{fOp("/", fNum("1"), fNum("0")), nil},
+		{fOp("%", fNum("1"), fNum("0")), nil},
+		{fFunc("sqrt", fNum("-1")), nil},
+		{fFunc("ln", fNum("0")), nil},
+		{fOp("+", fOp("/", fNum("1"), fNum("0")), fNum("1")), 1.0},
+	}
+	for _, tc := range tests {
+		f := tc.f
+		v, err := newFormulaEvaluator(row).eval(&f)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.exp, v)
+	}
+}
+
+func TestFormulaEvalErrors(t *testing.T) {
+	row := newFormulaTestRow()
+	tests := []FormulaArg{
+		fProp("Missing"),
+		fOp("-", fProp("Name"), fNum("1")),
+		fOp(">", fProp("Price"), fStr("x")),
+		fOp("not", fProp("Name")),
+		fFunc("missing"),
+		fFunc("concat", fProp("Price")),
+		fFunc("dateAdd", fProp("Due"), fNum("1"), fStr("eons")),
+		fFunc("if", fProp("Done")),
+	}
+	for _, f := range tests {
+		_, err := newFormulaEvaluator(row).eval(&f)
+		assert.Error(t, err)
+	}
+}
+
+func TestTableRowEvalFormula(t *testing.T) {
+	row := newFormulaTestRow()
+	// formula as sent by Notion:
+	// if(prop("Done"), concat(prop("Name"), " done"), format(prop("Price") * 2))
+	js := `{
+	"type": "conditional",
+	"result_type": "text",
+	"condition": {"type": "property", "id": "AbCd", "name": "Done", "result_type": "checkbox"},
+	"true": {
+		"type": "function", "name": "concat", "result_type": "text",
+		"args": [
+			{"type": "property", "id": "title", "name": "Name", "result_type": "text"},
+			{"type": "constant", "value": " done", "value_type": "string", "result_type": "text"}
+		]
+	},
+	"false": {
+		"type": "function", "name": "format", "result_type": "text",
+		"args": [{
+			"type": "operator", "operator": "*", "name": "multiply", "result_type": "number",
+			"args": [
+				{"type": "property", "id": "nmbr", "name": "Price", "result_type": "number"},
+				{"type": "constant", "value": "2", "value_type": "number", "result_type": "number"}
+			]
+		}]
+	}
+}`
+	var formula ColumnFormula
+	assert.NoError(t, jsonit.Unmarshal([]byte(js), &formula))
+	schema := row.TableView.Collection.Schema
+	schema["frml"].Formula = &formula
+
+	v, err := row.EvalFormula("Formula")
+	assert.NoError(t, err)
+	assert.Equal(t, "Buy milk done", v)
+
+	row.Page.Properties["AbCd"] = spans("No", "")
+	v, err = formula.Eval(row)
+	assert.NoError(t, err)
+	assert.Equal(t, "25", v)
+
+	// formulas can refer to other formulas
+	name := "length"
+	schema["frm2"] = &ColumnSchema{Name: "Length", Type: ColumnTypeFormula, Formula: &ColumnFormula{
+		Type: "function", Name: name, Args: []FormulaArg{fProp("Formula")},
+	}}
+	v, err = row.EvalFormula("Length")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, v)
+
+	// but not to themselves
+	formula.False = &FormulaArg{Type: "property", ID: "frm2"}
+	_, err = row.EvalFormula("Length")
+	assert.Error(t, err)
+
+	_, err = row.EvalFormula("Price")
+	assert.Error(t, err)
+}
+
+func TestFormulaHasTime(t *testing.T) {
+	dateOnly := func(s string) []interface{} {
+		d := map[string]interface{}{"type": "date", "start_date": s}
+		return []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"d", d}}}}
+	}
+	collection := &Collection{
+		ID: testBlockID(80),
+		Schema: map[string]*ColumnSchema{
+			"title": {Name: "Name", Type: ColumnTypeTitle},
+			"date":  {Name: "Due", Type: ColumnTypeDate},
+			"crtd":  {Name: "Created", Type: ColumnTypeCreatedTime},
+			"rltn":  {Name: "Parent", Type: ColumnTypeRelation, CollectionID: testBlockID(80)},
+			// rollup of itself through a relation to the same collection
+			"loop": {Name: "Loop", Type: ColumnTypeRollup, RelationProperty: "rltn", TargetProperty: "loop", Aggregation: "latest_date"},
+			"last": {Name: "Last", Type: ColumnTypeRollup, RelationProperty: "rltn", TargetProperty: "date", Aggregation: "latest_date"},
+		},
+	}
+	row := &TableRow{Page: &Block{ID: testBlockID(81), Properties: map[string]interface{}{
+		"date": dateOnly("2021-03-04"),
+		"rltn": spans("‣", "p "+testBlockID(82)),
+	}}}
+	other := &Block{ID: testBlockID(82), Properties: map[string]interface{}{
+		"date": dateOnly("2021-03-05"),
+		"rltn": spans("‣", "p "+testBlockID(81)),
+	}}
+	row.TableView = &TableView{
+		Collection:         collection,
+		relatedPages:       map[string]*Block{row.Page.ID: row.Page, other.ID: other},
+		relatedCollections: map[string]*Collection{collection.ID: collection},
+	}
+
+	e := newFormulaEvaluator(row)
+	assert.False(t, e.propertyHasTime("Due"))
+	assert.True(t, e.propertyHasTime("Created"))
+	assert.False(t, e.propertyHasTime("Last"))
+	assert.False(t, e.propertyHasTime("Loop"))
+	tests := []struct {
+		f   FormulaArg
+		exp bool
+	}{
+		{fFunc("dateAdd", fProp("date"), fNum("1"), fStr("days")), false},
+		{fFunc("dateAdd", fProp("date"), fNum("1"), fStr("hours")), true},
+		{fFunc("if", fProp("title"), fProp("date"), fFunc("now")), true},
+		{fFunc("fromTimestamp", fNum("0")), true},
+	}
+	for _, tc := range tests {
+		f := tc.f
+		assert.Equal(t, tc.exp, e.hasTime(&f))
+	}
+}
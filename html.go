@@ -0,0 +1,424 @@
+package notionapi
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// HTMLRenderFunc renders a block to HTML. It returns false if it didn't
+// render the block, in which case the default rendering is used
+type HTMLRenderFunc func(c *HTMLConverter, block *Block) bool
+
+// HTMLConverter converts a Page to HTML
+type HTMLConverter struct {
+	Page *Page
+
+	// RenderBlockOverrides allows over-riding rendering of blocks
+	// of a given type e.g. BlockCode or BlockImage
+	RenderBlockOverrides map[string]HTMLRenderFunc
+
+	// RewriteURL, if set, is called for every url we output (images, files,
+	// links and links to Notion pages) and returns url to use instead
+	// e.g. to point to a locally downloaded copy of an image.
+	// block is the block that contains the url
+	RewriteURL func(block *Block, uri string) string
+
+	buf bytes.Buffer
+}
+
+// NewHTMLConverter returns a converter of page to HTML
+func NewHTMLConverter(page *Page) *HTMLConverter {
+	return &HTMLConverter{
+		Page:                 page,
+		RenderBlockOverrides: map[string]HTMLRenderFunc{},
+	}
+}
+
+// ToHTML renders the page as HTML. The result is an <article> element,
+// without <html> or <body> wrapper
+func (c *HTMLConverter) ToHTML() []byte {
+	c.buf.Reset()
+	root := c.Page.Root()
+	if root == nil {
+		return nil
+	}
+	c.Printf(`<article id="%s" class="page">`+"\n", c.Anchor(root))
+	c.Printf(`<header><h1 class="page-title">%s</h1></header>`+"\n", html.EscapeString(blockTitle(root)))
+	c.WriteString(`<div class="page-body">` + "\n")
+	if root.Type == BlockCollectionViewPage {
+		c.renderTableViews(root)
+	} else {
+		c.RenderBlocks(root.Content)
+	}
+	c.WriteString("</div>\n</article>\n")
+	// buf is re-used by the next ToHTML
+	return append([]byte(nil), c.buf.Bytes()...)
+}
+
+// WriteString writes s to the output
+func (c *HTMLConverter) WriteString(s string) {
+	c.buf.WriteString(s)
+}
+
+// Printf writes formatted string to the output
+func (c *HTMLConverter) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(&c.buf, format, args...)
+}
+
+// Anchor returns id of the HTML element for a block.
+// It's stable because it's based on block id
+func (c *HTMLConverter) Anchor(block *Block) string {
+	return ToNoDashID(block.ID)
+}
+
+// URL returns uri, rewritten with RewriteURL if set
+func (c *HTMLConverter) URL(block *Block, uri string) string {
+	if c.RewriteURL == nil || uri == "" {
+		return uri
+	}
+	return c.RewriteURL(block, uri)
+}
+
+func (c *HTMLConverter) pageByID(id string) *Block {
+	nid := NewNotionID(id)
+	if c.Page == nil || nid == nil {
+		return nil
+	}
+	return c.Page.BlockByID(nid)
+}
+
+// InlineToHTML converts text spans of a block to HTML
+func (c *HTMLConverter) InlineToHTML(block *Block, spans []*TextSpan) string {
+	rewriteURL := func(uri string) string {
+		return c.URL(block, uri)
+	}
+	return newTextSpansFormatter(c.Page, textFormatHTML, rewriteURL).format(spans)
+}
+
+func (c *HTMLConverter) inline(block *Block) string {
+	return c.InlineToHTML(block, block.InlineContent)
+}
+
+// listTag returns a tag of a list element for list blocks
+// and "" for other blocks
+func listTag(block *Block) string {
+	switch block.Type {
+	case BlockBulletedList:
+		return "ul"
+	case BlockNumberedList:
+		return "ol"
+	case BlockTodo:
+		return `ul class="to-do-list"`
+	}
+	return ""
+}
+
+// RenderBlocks renders sibling blocks. Consecutive list items are
+// grouped in <ul> or <ol>
+func (c *HTMLConverter) RenderBlocks(blocks []*Block) {
+	currList := ""
+	closeList := func() {
+		if currList != "" {
+			tag := strings.Split(currList, " ")[0]
+			c.WriteString("</" + tag + ">\n")
+			currList = ""
+		}
+	}
+	for _, block := range blocks {
+		tag := listTag(block)
+		if tag != currList {
+			closeList()
+			if tag != "" {
+				c.WriteString("<" + tag + ">\n")
+				currList = tag
+			}
+		}
+		c.RenderBlock(block)
+	}
+	closeList()
+}
+
+// RenderChildren renders children of the block
+func (c *HTMLConverter) RenderChildren(block *Block) {
+	c.RenderBlocks(block.Content)
+}
+
+// RenderBlock renders a block using override from RenderBlockOverrides,
+// if there is one, or DefaultRenderBlock
+func (c *HTMLConverter) RenderBlock(block *Block) {
+	if fn := c.RenderBlockOverrides[block.Type]; fn != nil {
+		if fn(c, block) {
+			return
+		}
+	}
+	c.DefaultRenderBlock(block)
+}
+
+func (c *HTMLConverter) renderIndentedChildren(block *Block) {
+	if len(block.Content) == 0 {
+		return
+	}
+	c.WriteString(`<div class="indented">` + "\n")
+	c.RenderChildren(block)
+	c.WriteString("</div>\n")
+}
+
+func (c *HTMLConverter) renderHeader(block *Block, tag string) {
+	c.Printf(`<%s id="%s">%s</%s>`+"\n", tag, c.Anchor(block), c.inline(block), tag)
+	c.renderIndentedChildren(block)
+}
+
+func (c *HTMLConverter) renderListItem(block *Block) {
+	c.Printf(`<li id="%s">`, c.Anchor(block))
+	if block.Type == BlockTodo {
+		checked := ""
+		if block.IsChecked {
+			checked = " checked"
+		}
+		c.Printf(`<input type="checkbox" disabled%s> `, checked)
+	}
+	c.WriteString(c.inline(block))
+	if len(block.Content) > 0 {
+		c.WriteString("\n")
+		c.RenderChildren(block)
+	}
+	c.WriteString("</li>\n")
+}
+
+func (c *HTMLConverter) renderCode(block *Block) {
+	lang := strings.ToLower(block.CodeLanguage)
+	cls := ""
+	if lang != "" && lang != "plain text" {
+		cls = fmt.Sprintf(` class="language-%s"`, html.EscapeString(strings.ReplaceAll(lang, " ", "-")))
+	}
+	c.Printf(`<pre id="%s"><code%s>%s</code></pre>`+"\n", c.Anchor(block), cls, html.EscapeString(block.Code))
+}
+
+func (c *HTMLConverter) renderCaption(block *Block) {
+	if caption := block.GetCaption(); len(caption) > 0 {
+		c.Printf("<figcaption>%s</figcaption>", c.InlineToHTML(block, caption))
+	}
+}
+
+func (c *HTMLConverter) renderImage(block *Block) {
+	uri := c.URL(block, block.Source)
+	alt := TextSpansToString(block.GetCaption())
+	c.Printf(`<figure id="%s" class="image"><img src="%s" alt="%s">`, c.Anchor(block), html.EscapeString(uri), html.EscapeString(alt))
+	c.renderCaption(block)
+	c.WriteString("</figure>\n")
+}
+
+func (c *HTMLConverter) renderFile(block *Block) {
+	uri := c.URL(block, block.Source)
+	title := block.Title
+	if title == "" {
+		title = block.Source
+	}
+	c.Printf(`<figure id="%s" class="file">`, c.Anchor(block))
+	switch block.Type {
+	case BlockAudio:
+		c.Printf(`<audio controls src="%s"></audio>`, html.EscapeString(uri))
+	case BlockVideo:
+		c.Printf(`<video controls src="%s"></video>`, html.EscapeString(uri))
+	default:
+		c.Printf(`<a href="%s">%s</a>`, html.EscapeString(uri), html.EscapeString(title))
+		if block.FileSize != "" {
+			c.Printf(` <span class="file-size">%s</span>`, html.EscapeString(block.FileSize))
+		}
+	}
+	c.renderCaption(block)
+	c.WriteString("</figure>\n")
+}
+
+func (c *HTMLConverter) renderBookmark(block *Block) {
+	uri := c.URL(block, block.Link)
+	title := block.Title
+	if title == "" {
+		title = block.Link
+	}
+	c.Printf(`<figure id="%s" class="bookmark"><a href="%s">%s</a>`, c.Anchor(block), html.EscapeString(uri), html.EscapeString(title))
+	if block.Description != "" {
+		c.Printf(`<div class="bookmark-description">%s</div>`, html.EscapeString(block.Description))
+	}
+	c.renderCaption(block)
+	c.WriteString("</figure>\n")
+}
+
+func (c *HTMLConverter) renderEmbed(block *Block) {
+	uri := block.Source
+	if uri == "" {
+		uri = block.Link
+	}
+	if uri == "" {
+		return
+	}
+	uri = c.URL(block, uri)
+	c.Printf(`<figure id="%s" class="embed">`, c.Anchor(block))
+	switch block.Type {
+	case BlockTweet, BlockGist, BlockDrive:
+		// those can't be shown in an iframe
+		c.Printf(`<a href="%s">%s</a>`, html.EscapeString(uri), html.EscapeString(uri))
+	default:
+		c.Printf(`<iframe src="%s"></iframe>`, html.EscapeString(uri))
+	}
+	c.renderCaption(block)
+	c.WriteString("</figure>\n")
+}
+
+func (c *HTMLConverter) renderLinkToPage(block *Block, pageID string) {
+	if pageID == "" {
+		return
+	}
+	title := "Untitled"
+	if b := c.pageByID(pageID); b != nil {
+		title = blockTitle(b)
+	}
+	uri := c.URL(block, pageURL(pageID))
+	c.Printf(`<figure id="%s" class="link-to-page"><a href="%s">%s</a></figure>`+"\n", c.Anchor(block), html.EscapeString(uri), html.EscapeString(title))
+}
+
+func (c *HTMLConverter) renderColumnList(block *Block) {
+	c.Printf(`<div id="%s" class="column-list">`+"\n", c.Anchor(block))
+	for _, col := range block.Content {
+		style := ""
+		if col.Type == BlockColumn {
+			if f := col.FormatColumn(); f != nil && f.ColumnRatio > 0 {
+				style = fmt.Sprintf(` style="width:%g%%"`, f.ColumnRatio*100)
+			}
+		}
+		c.Printf(`<div id="%s" class="column"%s>`+"\n", c.Anchor(col), style)
+		c.RenderChildren(col)
+		c.WriteString("</div>\n")
+	}
+	c.WriteString("</div>\n")
+}
+
+// renderTableOfContents renders links to headers of the page
+func (c *HTMLConverter) renderTableOfContents(block *Block) {
+	root := c.Page.Root()
+	if root == nil {
+		return
+	}
+	c.Printf(`<nav id="%s" class="table-of-contents">`+"\n", c.Anchor(block))
+	forEachHeader(root.Content, func(b *Block) {
+		level := 0
+		switch b.Type {
+		case BlockSubHeader:
+			level = 1
+		case BlockSubSubHeader:
+			level = 2
+		}
+		title := html.EscapeString(TextSpansToPlainText(c.Page, b.InlineContent))
+		c.Printf(`<div class="table-of-contents-item table-of-contents-indent-%d"><a href="#%s">%s</a></div>`+"\n", level, c.Anchor(b), title)
+	})
+	c.WriteString("</nav>\n")
+}
+
+func (c *HTMLConverter) renderTableView(tv *TableView) {
+	if len(tv.Columns) == 0 {
+		return
+	}
+	c.WriteString("<table>\n<thead><tr>")
+	for _, col := range tv.Columns {
+		c.Printf("<th>%s</th>", html.EscapeString(col.Name()))
+	}
+	c.WriteString("</tr></thead>\n<tbody>\n")
+	for _, row := range tv.Rows {
+		c.Printf(`<tr id="%s">`, c.Anchor(row.Page))
+		for _, col := range tv.Columns {
+			c.Printf("<td>%s</td>", c.InlineToHTML(row.Page, row.Page.GetProperty(col.ID())))
+		}
+		c.WriteString("</tr>\n")
+	}
+	c.WriteString("</tbody>\n</table>\n")
+}
+
+func (c *HTMLConverter) renderTableViews(block *Block) {
+	c.Printf(`<div id="%s" class="collection-content">`+"\n", c.Anchor(block))
+	if block.Type != BlockCollectionViewPage && len(block.TableViews) > 0 {
+		c.Printf(`<h4 class="collection-title">%s</h4>`+"\n", html.EscapeString(blockTitle(block)))
+	}
+	for _, tv := range block.TableViews {
+		c.renderTableView(tv)
+	}
+	c.WriteString("</div>\n")
+}
+
+// DefaultRenderBlock renders a block without using RenderBlockOverrides.
+// Can be used by overrides that only want to wrap default rendering
+func (c *HTMLConverter) DefaultRenderBlock(block *Block) {
+	switch block.Type {
+	case BlockText:
+		c.Printf(`<p id="%s">%s</p>`+"\n", c.Anchor(block), c.inline(block))
+		c.renderIndentedChildren(block)
+	case BlockHeader:
+		c.renderHeader(block, "h1")
+	case BlockSubHeader:
+		c.renderHeader(block, "h2")
+	case BlockSubSubHeader:
+		c.renderHeader(block, "h3")
+	case BlockBulletedList, BlockNumberedList, BlockTodo:
+		c.renderListItem(block)
+	case BlockToggle:
+		c.Printf(`<details id="%s"><summary>%s</summary>`+"\n", c.Anchor(block), c.inline(block))
+		c.RenderChildren(block)
+		c.WriteString("</details>\n")
+	case BlockQuote:
+		c.Printf(`<blockquote id="%s">%s`, c.Anchor(block), c.inline(block))
+		if len(block.Content) > 0 {
+			c.WriteString("\n")
+			c.RenderChildren(block)
+		}
+		c.WriteString("</blockquote>\n")
+	case BlockCallout:
+		c.Printf(`<figure id="%s" class="callout">`, c.Anchor(block))
+		if icon := block.Format.PageIcon; icon != "" {
+			if strings.HasPrefix(icon, "http") {
+				c.Printf(`<div class="icon"><img src="%s"></div>`, html.EscapeString(c.URL(block, icon)))
+			} else {
+				c.Printf(`<div class="icon">%s</div>`, html.EscapeString(icon))
+			}
+		}
+		c.Printf("<div>%s", c.inline(block))
+		if len(block.Content) > 0 {
+			c.WriteString("\n")
+			c.RenderChildren(block)
+		}
+		c.WriteString("</div></figure>\n")
+	case BlockCode:
+		c.renderCode(block)
+	case BlockEquation:
+		expr := TextSpansToString(block.InlineContent)
+		c.Printf(`<figure id="%s" class="equation">$$%s$$</figure>`+"\n", c.Anchor(block), html.EscapeString(expr))
+	case BlockDivider:
+		c.Printf(`<hr id="%s">`+"\n", c.Anchor(block))
+	case BlockImage:
+		c.renderImage(block)
+	case BlockFile, BlockPDF, BlockAudio, BlockVideo:
+		c.renderFile(block)
+	case BlockBookmark:
+		c.renderBookmark(block)
+	case BlockEmbed, BlockTweet, BlockGist, BlockCodepen, BlockFigma,
+		BlockMaps, BlockDrive, BlockMiro:
+		c.renderEmbed(block)
+	case BlockPage, BlockCollectionViewPage:
+		c.renderLinkToPage(block, block.ID)
+	case BlockAlias, BlockLinkToPage:
+		c.renderLinkToPage(block, aliasPageID(block))
+	case BlockCollectionView, BlockLinkToCollection:
+		c.renderTableViews(block)
+	case BlockTableOfContents:
+		c.renderTableOfContents(block)
+	case BlockColumnList:
+		c.renderColumnList(block)
+	case BlockColumn, BlockTransclusionReference:
+		c.RenderChildren(block)
+	case BlockBreadcrumb, BlockComment, BlockFactory, BlockCopyIndicator:
+		// not meaningful outside of Notion
+	default:
+		c.Printf(`<div id="%s">%s</div>`+"\n", c.Anchor(block), c.inline(block))
+		c.renderIndentedChildren(block)
+	}
+}
@@ -0,0 +1,78 @@
+package notionapi
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+func TestHTMLConverter(t *testing.T) {
+	root := &testBlock{
+		Type:  BlockPage,
+		Props: titleProp("My <page>", ""),
+		Children: []*testBlock{
+			{Type: BlockTableOfContents},
+			{Type: BlockHeader, Props: titleProp("Header", "")},
+			{Type: BlockText, Props: titleProp("plain ", "", "bold", "b,i", " ", "", "link", "a https://blog.kowalczyk.info")},
+			{Type: BlockBulletedList, Props: titleProp("one", "")},
+			{Type: BlockBulletedList, Props: titleProp("two", "")},
+			{Type: BlockSubHeader, Props: titleProp("Sub header", "")},
+			{Type: BlockTodo, Props: map[string]interface{}{
+				"title":   spans("done", ""),
+				"checked": spans("Yes", ""),
+			}},
+			{Type: BlockCode, Props: map[string]interface{}{
+				"title":    spans("a < b", ""),
+				"language": spans("Go", ""),
+			}},
+			{Type: BlockImage, Props: map[string]interface{}{
+				"source": spans("https://example.com/img.png", ""),
+			}},
+		},
+	}
+	p := newTestPage(t, root)
+	c := NewHTMLConverter(p)
+	c.RewriteURL = func(block *Block, uri string) string {
+		if block.Type == BlockImage {
+			return "img.png"
+		}
+		return uri
+	}
+	first := c.ToHTML()
+	s := string(first)
+
+	id := func(n int) string {
+		return ToNoDashID(testBlockID(n))
+	}
+	exp := []string{
+		`<article id="` + id(1) + `" class="page">`,
+		`<h1 class="page-title">My &lt;page&gt;</h1>`,
+		`<div class="table-of-contents-item table-of-contents-indent-0"><a href="#` + id(3) + `">Header</a></div>`,
+		`<div class="table-of-contents-item table-of-contents-indent-1"><a href="#` + id(7) + `">Sub header</a></div>`,
+		`<h1 id="` + id(3) + `">Header</h1>`,
+		`<p id="` + id(4) + `">plain <strong><em>bold</em></strong> <a href="https://blog.kowalczyk.info">link</a></p>`,
+		"<ul>\n<li id=\"" + id(5) + "\">one</li>\n<li id=\"" + id(6) + "\">two</li>\n</ul>",
+		`<ul class="to-do-list">` + "\n" + `<li id="` + id(8) + `"><input type="checkbox" disabled checked> done</li>` + "\n</ul>",
+		`<pre id="` + id(9) + `"><code class="language-go">a &lt; b</code></pre>`,
+		`<img src="img.png" alt="">`,
+	}
+	for _, e := range exp {
+		assert.True(t, strings.Contains(s, e), "'%s' not in:\n%s", e, s)
+	}
+
+	c.RenderBlockOverrides[BlockCode] = func(c *HTMLConverter, block *Block) bool {
+		c.Printf(`<div class="my-code">%s</div>`, block.Code)
+		return true
+	}
+	c.RenderBlockOverrides[BlockImage] = func(c *HTMLConverter, block *Block) bool {
+		// fall back to default rendering
+		return false
+	}
+	s = string(c.ToHTML())
+	assert.True(t, strings.Contains(s, `<div class="my-code">a < b</div>`))
+	// the first result is not over-written
+	assert.True(t, strings.Contains(string(first), "<pre"))
+	assert.False(t, strings.Contains(s, "<pre"))
+	assert.True(t, strings.Contains(s, `<img src="img.png" alt="">`))
+}
@@ -0,0 +1,325 @@
+package notionapi
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+const (
+	textFormatPlain = iota
+	textFormatHTML
+	textFormatMarkdown
+)
+
+// textSpansFormatter formats []*TextSpan as HTML, markdown or plain text.
+// Formatting attributes of adjacent spans are merged, so that e.g.
+// a bold span followed by bold+italic span becomes
+// <strong>a<em>b</em></strong> and not <strong>a</strong><strong><em>b</em></strong>
+type textSpansFormatter struct {
+	page *Page
+	kind int
+	// if set, rewrites urls of links and page mentions
+	rewriteURL func(uri string) string
+
+	sb strings.Builder
+	// currently open formatting attributes, outer-most first
+	stack []TextAttr
+	// in markdown, trailing whitespace is moved after closing markers
+	// because "**bold **" is not bold
+	pendingSpace string
+}
+
+// order in which formatting attributes are nested, outer-most first
+var attrNestingOrder = map[string]int{
+	AttrLink:           0,
+	AttrHighlight:      1,
+	AttrBold:           2,
+	AttrItalic:         3,
+	AttrStrikeThrought: 4,
+	AttrCode:           5,
+}
+
+func newTextSpansFormatter(page *Page, kind int, rewriteURL func(string) string) *textSpansFormatter {
+	return &textSpansFormatter{
+		page:       page,
+		kind:       kind,
+		rewriteURL: rewriteURL,
+	}
+}
+
+func (f *textSpansFormatter) url(uri string) string {
+	if f.rewriteURL == nil || uri == "" {
+		return uri
+	}
+	return f.rewriteURL(uri)
+}
+
+func (f *textSpansFormatter) escape(s string) string {
+	switch f.kind {
+	case textFormatHTML:
+		return html.EscapeString(s)
+	case textFormatMarkdown:
+		return markdownEscaper.Replace(s)
+	}
+	return s
+}
+
+func attrsEqual(a1, a2 TextAttr) bool {
+	if len(a1) != len(a2) {
+		return false
+	}
+	for i := range a1 {
+		if a1[i] != a2[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formattingAttrs returns formatting attributes of ts in the order
+// of nesting, without duplicates
+func (f *textSpansFormatter) formattingAttrs(ts *TextSpan) []TextAttr {
+	if f.kind == textFormatPlain {
+		return nil
+	}
+	var res []TextAttr
+	for _, attr := range ts.Attrs {
+		typ := AttrGetType(attr)
+		if _, ok := attrNestingOrder[typ]; !ok {
+			continue
+		}
+		// markdown doesn't have highlight
+		if typ == AttrHighlight && f.kind == textFormatMarkdown {
+			continue
+		}
+		dup := false
+		for _, a := range res {
+			if AttrGetType(a) == typ {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			res = append(res, attr)
+		}
+	}
+	// insertion sort, attributes are few
+	for i := 1; i < len(res); i++ {
+		for j := i; j > 0 && attrNestingOrder[AttrGetType(res[j])] < attrNestingOrder[AttrGetType(res[j-1])]; j-- {
+			res[j], res[j-1] = res[j-1], res[j]
+		}
+	}
+	return res
+}
+
+func (f *textSpansFormatter) open(attr TextAttr) string {
+	typ := AttrGetType(attr)
+	if f.kind == textFormatMarkdown {
+		switch typ {
+		case AttrLink:
+			return "["
+		case AttrBold:
+			return "**"
+		case AttrItalic:
+			return "*"
+		case AttrStrikeThrought:
+			return "~~"
+		case AttrCode:
+			return "`"
+		}
+		return ""
+	}
+	switch typ {
+	case AttrLink:
+		return fmt.Sprintf(`<a href="%s">`, html.EscapeString(f.url(AttrGetLink(attr))))
+	case AttrHighlight:
+		return fmt.Sprintf(`<mark class="highlight-%s">`, html.EscapeString(AttrGetHighlight(attr)))
+	case AttrBold:
+		return "<strong>"
+	case AttrItalic:
+		return "<em>"
+	case AttrStrikeThrought:
+		return "<del>"
+	case AttrCode:
+		return "<code>"
+	}
+	return ""
+}
+
+func (f *textSpansFormatter) close(attr TextAttr) string {
+	typ := AttrGetType(attr)
+	if f.kind == textFormatMarkdown {
+		switch typ {
+		case AttrLink:
+			return "](" + f.url(AttrGetLink(attr)) + ")"
+		case AttrBold:
+			return "**"
+		case AttrItalic:
+			return "*"
+		case AttrStrikeThrought:
+			return "~~"
+		case AttrCode:
+			return "`"
+		}
+		return ""
+	}
+	switch typ {
+	case AttrLink:
+		return "</a>"
+	case AttrHighlight:
+		return "</mark>"
+	case AttrBold:
+		return "</strong>"
+	case AttrItalic:
+		return "</em>"
+	case AttrStrikeThrought:
+		return "</del>"
+	case AttrCode:
+		return "</code>"
+	}
+	return ""
+}
+
+func (f *textSpansFormatter) pop() {
+	n := len(f.stack) - 1
+	f.sb.WriteString(f.close(f.stack[n]))
+	f.stack = f.stack[:n]
+}
+
+func (f *textSpansFormatter) flushPendingSpace() {
+	f.sb.WriteString(f.pendingSpace)
+	f.pendingSpace = ""
+}
+
+func (f *textSpansFormatter) pageTitle(pageID string) string {
+	if f.page != nil {
+		if nid := NewNotionID(pageID); nid != nil {
+			if b := f.page.BlockByID(nid); b != nil {
+				return blockTitle(b)
+			}
+		}
+	}
+	return "Untitled"
+}
+
+func (f *textSpansFormatter) userName(userID string) string {
+	if f.page == nil {
+		return userID
+	}
+	return GetUserNameByID(f.page, userID)
+}
+
+// content returns formatted content of ts, without formatting attributes.
+// User, page and date mentions are resolved
+func (f *textSpansFormatter) content(ts *TextSpan) string {
+	for _, attr := range ts.Attrs {
+		switch AttrGetType(attr) {
+		case AttrUser:
+			name := "@" + f.userName(AttrGetUserID(attr))
+			if f.kind == textFormatHTML {
+				return `<span class="user">` + html.EscapeString(name) + "</span>"
+			}
+			return f.escape(name)
+		case AttrDate:
+			s := FormatDate(AttrGetDate(attr))
+			if f.kind == textFormatHTML {
+				return "<time>" + html.EscapeString(s) + "</time>"
+			}
+			return f.escape(s)
+		case AttrPage:
+			pageID := AttrGetPageID(attr)
+			title := f.pageTitle(pageID)
+			uri := f.url(pageURL(pageID))
+			switch f.kind {
+			case textFormatHTML:
+				return fmt.Sprintf(`<a class="page-mention" href="%s">%s</a>`, html.EscapeString(uri), html.EscapeString(title))
+			case textFormatMarkdown:
+				return fmt.Sprintf("[%s](%s)", f.escape(title), uri)
+			}
+			return title
+		}
+	}
+	if f.kind == textFormatMarkdown {
+		for _, attr := range ts.Attrs {
+			// inside `code` nothing is interpreted
+			if AttrGetType(attr) == AttrCode {
+				return ts.Text
+			}
+		}
+	}
+	return f.escape(ts.Text)
+}
+
+// splitSpace splits s into leading whitespace, the rest and trailing whitespace
+func splitSpace(s string) (string, string, string) {
+	body := strings.TrimLeft(s, " \t\n")
+	lead := s[:len(s)-len(body)]
+	trimmed := strings.TrimRight(body, " \t\n")
+	return lead, trimmed, body[len(trimmed):]
+}
+
+func (f *textSpansFormatter) span(ts *TextSpan) {
+	content := f.content(ts)
+	lead, body, trail := "", content, ""
+	if f.kind == textFormatMarkdown {
+		lead, body, trail = splitSpace(content)
+		if body == "" {
+			// whitespace doesn't need formatting so it doesn't
+			// close formatting of the previous span
+			f.pendingSpace += content
+			return
+		}
+	}
+
+	attrs := f.formattingAttrs(ts)
+	// keep attributes shared with the previous span open
+	n := 0
+	for n < len(f.stack) && n < len(attrs) && attrsEqual(f.stack[n], attrs[n]) {
+		n++
+	}
+	for len(f.stack) > n {
+		f.pop()
+	}
+	f.flushPendingSpace()
+	f.sb.WriteString(lead)
+	for _, attr := range attrs[n:] {
+		f.sb.WriteString(f.open(attr))
+		f.stack = append(f.stack, attr)
+	}
+	f.sb.WriteString(body)
+	if len(f.stack) > 0 {
+		f.pendingSpace = trail
+	} else {
+		f.sb.WriteString(trail)
+	}
+}
+
+func (f *textSpansFormatter) format(spans []*TextSpan) string {
+	for _, ts := range spans {
+		f.span(ts)
+	}
+	for len(f.stack) > 0 {
+		f.pop()
+	}
+	f.flushPendingSpace()
+	return f.sb.String()
+}
+
+// TextSpansToHTML formats text spans as HTML. User, page and date
+// mentions are resolved using page, which can be nil
+func TextSpansToHTML(page *Page, spans []*TextSpan) string {
+	return newTextSpansFormatter(page, textFormatHTML, nil).format(spans)
+}
+
+// TextSpansToMarkdown formats text spans as markdown. User, page and date
+// mentions are resolved using page, which can be nil
+func TextSpansToMarkdown(page *Page, spans []*TextSpan) string {
+	return newTextSpansFormatter(page, textFormatMarkdown, nil).format(spans)
+}
+
+// TextSpansToPlainText is like TextSpansToString but resolves user, page
+// and date mentions using page, which can be nil
+func TextSpansToPlainText(page *Page, spans []*TextSpan) string {
+	return newTextSpansFormatter(page, textFormatPlain, nil).format(spans)
+}
@@ -0,0 +1,102 @@
+package notionapi
+
+import (
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+func mustParseSpans(t *testing.T, raw []interface{}) []*TextSpan {
+	ts, err := ParseTextSpans(raw)
+	assert.NoError(t, err)
+	return ts
+}
+
+func TestTextSpansFormatting(t *testing.T) {
+	tests := []struct {
+		spans    []interface{}
+		html     string
+		markdown string
+		plain    string
+	}{
+		{
+			spans("plain", ""),
+			"plain",
+			"plain",
+			"plain",
+		},
+		{
+			spans("a ", "b", "b", "b,i", " c", ""),
+			"<strong>a <em>b</em></strong> c",
+			"**a *b*** c",
+			"a b c",
+		},
+		{
+			spans("bold ", "b", "still bold", "b"),
+			"<strong>bold still bold</strong>",
+			"**bold still bold**",
+			"bold still bold",
+		},
+		{
+			// attributes are nested in fixed order, regardless of their order in span
+			spans("x", "i,b", "y", "b"),
+			"<strong><em>x</em>y</strong>",
+			"***x*y**",
+			"xy",
+		},
+		{
+			spans("link ", "a https://example.com", "bold", "b,a https://example.com"),
+			`<a href="https://example.com">link <strong>bold</strong></a>`,
+			"[link **bold**](https://example.com)",
+			"link bold",
+		},
+		{
+			spans("a*b", "", "x_y", "c"),
+			"a*b<code>x_y</code>",
+			"a\\*b`x_y`",
+			"a*bx_y",
+		},
+		{
+			spans("<marked>", "h yellow_background"),
+			`<mark class="highlight-yellow_background">&lt;marked&gt;</mark>`,
+			"\\<marked\\>",
+			"<marked>",
+		},
+	}
+	for _, tc := range tests {
+		ts := mustParseSpans(t, tc.spans)
+		assert.Equal(t, tc.html, TextSpansToHTML(nil, ts))
+		assert.Equal(t, tc.markdown, TextSpansToMarkdown(nil, ts))
+		assert.Equal(t, tc.plain, TextSpansToPlainText(nil, ts))
+	}
+}
+
+func TestTextSpansMentions(t *testing.T) {
+	p := newTestPage(t, &testBlock{
+		Type:  BlockPage,
+		Props: titleProp("Page", ""),
+		Children: []*testBlock{
+			{Type: BlockPage, Props: titleProp("Sub page", "")},
+		},
+	})
+	p.UserRecords = []*Record{
+		{NotionUser: &NotionUser{ID: "user-1", GivenName: "John", FamilyName: "Doe"}},
+	}
+	raw := []interface{}{
+		[]interface{}{TextSpanSpecial, []interface{}{[]interface{}{"u", "user-1"}}},
+		[]interface{}{" on "},
+		[]interface{}{TextSpanSpecial, []interface{}{[]interface{}{"d", map[string]interface{}{
+			"type":       "date",
+			"start_date": "2021-03-04",
+		}}}},
+		[]interface{}{" in "},
+		[]interface{}{TextSpanSpecial, []interface{}{[]interface{}{"p", testBlockID(2)}}},
+	}
+	ts := mustParseSpans(t, raw)
+	assert.Equal(t, "@John Doe on Mar 04, 2021 in Sub page", TextSpansToPlainText(p, ts))
+
+	uri := "https://www.notion.so/" + ToNoDashID(testBlockID(2))
+	assert.Equal(t, "@John Doe on Mar 04, 2021 in [Sub page]("+uri+")", TextSpansToMarkdown(p, ts))
+	exp := `<span class="user">@John Doe</span> on <time>Mar 04, 2021</time> in <a class="page-mention" href="` + uri + `">Sub page</a>`
+	assert.Equal(t, exp, TextSpansToHTML(p, ts))
+}
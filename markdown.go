@@ -0,0 +1,422 @@
+package notionapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MarkdownOptions allows customizing ToMarkdown
+type MarkdownOptions struct {
+	// RewriteURL, if set, is called for every url we output (images, files,
+	// links and links to Notion pages) and returns url to use instead
+	// e.g. to point to a locally downloaded copy of an image.
+	// block is the block that contains the url
+	RewriteURL func(block *Block, uri string) string
+}
+
+type markdownConverter struct {
+	page *Page
+	opts *MarkdownOptions
+}
+
+// ToMarkdown converts a page to markdown (GitHub flavored).
+// opts can be nil
+func ToMarkdown(page *Page, opts *MarkdownOptions) string {
+	if opts == nil {
+		opts = &MarkdownOptions{}
+	}
+	c := &markdownConverter{
+		page: page,
+		opts: opts,
+	}
+	root := page.Root()
+	if root == nil {
+		return ""
+	}
+	s := "# " + c.escape(blockTitle(root)) + "\n\n"
+	var body string
+	if root.Type == BlockCollectionViewPage {
+		body = c.tableViews(root)
+	} else {
+		body = c.blocks(root.Content)
+	}
+	if body != "" {
+		s += body + "\n"
+	}
+	return s
+}
+
+// BlockToMarkdown converts a block and its children to markdown.
+// opts can be nil
+func BlockToMarkdown(block *Block, opts *MarkdownOptions) string {
+	if opts == nil {
+		opts = &MarkdownOptions{}
+	}
+	c := &markdownConverter{
+		page: block.Page,
+		opts: opts,
+	}
+	s := c.block(block, 1)
+	if s != "" {
+		s += "\n"
+	}
+	return s
+}
+
+func (c *markdownConverter) rewriteURL(block *Block, uri string) string {
+	if c.opts.RewriteURL == nil || uri == "" {
+		return uri
+	}
+	return c.opts.RewriteURL(block, uri)
+}
+
+// pageURL returns url of a Notion page with a given id
+func pageURL(pageID string) string {
+	return "https://www.notion.so/" + ToNoDashID(pageID)
+}
+
+// blockTitle returns title of a page, collection view page or
+// collection view block
+func blockTitle(block *Block) string {
+	var s string
+	switch block.Type {
+	case BlockCollectionView, BlockCollectionViewPage:
+		if len(block.TableViews) > 0 && block.TableViews[0].Collection != nil {
+			s = block.TableViews[0].Collection.GetName()
+		}
+	default:
+		s = block.Title
+		if s == "" {
+			s = TextSpansToString(block.InlineContent)
+		}
+	}
+	if s == "" {
+		s = "Untitled"
+	}
+	return s
+}
+
+// pageByID returns a block with a given id if it's part of the page
+func (c *markdownConverter) pageByID(id string) *Block {
+	nid := NewNotionID(id)
+	if c.page == nil || nid == nil {
+		return nil
+	}
+	return c.page.BlockByID(nid)
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	`*`, `\*`,
+	`_`, `\_`,
+	`~`, `\~`,
+	`[`, `\[`,
+	`]`, `\]`,
+	`<`, `\<`,
+	`>`, `\>`,
+)
+
+func (c *markdownConverter) escape(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+func (c *markdownConverter) textSpans(block *Block, spans []*TextSpan) string {
+	rewriteURL := func(uri string) string {
+		return c.rewriteURL(block, uri)
+	}
+	return newTextSpansFormatter(c.page, textFormatMarkdown, rewriteURL).format(spans)
+}
+
+func (c *markdownConverter) inline(block *Block) string {
+	return c.textSpans(block, block.InlineContent)
+}
+
+// indent prefixes every line of s with prefix
+func indent(s string, prefix string) string {
+	lines := strings.Split(s, "\n")
+	emptyPrefix := strings.TrimRight(prefix, " ")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = emptyPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isListBlock(block *Block) bool {
+	switch block.Type {
+	case BlockBulletedList, BlockNumberedList, BlockTodo:
+		return true
+	}
+	return false
+}
+
+// blocks converts a list of sibling blocks. Blocks are separated by
+// an empty line, except for consecutive list items
+func (c *markdownConverter) blocks(blocks []*Block) string {
+	var sb strings.Builder
+	var prev *Block
+	listNo := 0
+	for _, block := range blocks {
+		if block.Type == BlockNumberedList {
+			listNo++
+		} else {
+			listNo = 0
+		}
+		s := c.block(block, listNo)
+		if s == "" {
+			continue
+		}
+		if prev != nil {
+			if isListBlock(prev) && isListBlock(block) {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+		sb.WriteString(s)
+		prev = block
+	}
+	return sb.String()
+}
+
+// withChildren appends children of the block, indented by prefix
+func (c *markdownConverter) withChildren(s string, block *Block, prefix string) string {
+	children := c.blocks(block.Content)
+	if children == "" {
+		return s
+	}
+	return s + "\n\n" + indent(children, prefix)
+}
+
+// listItem formats a list item with marker. Children are indented
+// to align with the text of the item
+func (c *markdownConverter) listItem(block *Block, marker string) string {
+	s := marker + c.inline(block)
+	children := c.blocks(block.Content)
+	if children == "" {
+		return s
+	}
+	return s + "\n" + indent(children, strings.Repeat(" ", len(marker)))
+}
+
+func (c *markdownConverter) link(block *Block, title string, uri string) string {
+	if title == "" {
+		title = uri
+	}
+	return fmt.Sprintf("[%s](%s)", c.escape(title), c.rewriteURL(block, uri))
+}
+
+// aliasPageID returns id of the page that BlockAlias or BlockLinkToPage
+// points to
+func aliasPageID(block *Block) string {
+	if id, ok := block.PropAsString("format.alias_pointer.id"); ok {
+		return id
+	}
+	return ""
+}
+
+func (c *markdownConverter) linkToPage(block *Block, pageID string) string {
+	if pageID == "" {
+		return ""
+	}
+	title := "Untitled"
+	if b := c.pageByID(pageID); b != nil {
+		title = blockTitle(b)
+	}
+	return c.link(block, title, pageURL(pageID))
+}
+
+func (c *markdownConverter) code(block *Block) string {
+	lang := strings.ToLower(block.CodeLanguage)
+	if lang == "plain text" {
+		lang = ""
+	}
+	code := block.Code
+	// use a fence longer than any sequence of backticks in the code
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + code + "\n" + fence
+}
+
+// toc builds a table of contents from headers of the page
+func (c *markdownConverter) toc(block *Block) string {
+	if c.page == nil || c.page.Root() == nil {
+		return ""
+	}
+	var lines []string
+	forEachHeader(c.page.Root().Content, func(b *Block) {
+		level := 0
+		switch b.Type {
+		case BlockHeader:
+			level = 0
+		case BlockSubHeader:
+			level = 1
+		case BlockSubSubHeader:
+			level = 2
+		default:
+			return
+		}
+		title := TextSpansToPlainText(c.page, b.InlineContent)
+		s := strings.Repeat("  ", level) + fmt.Sprintf("- [%s](#%s)", c.escape(title), markdownAnchor(title))
+		lines = append(lines, s)
+	})
+	return strings.Join(lines, "\n")
+}
+
+// forEachHeader calls cb for every header block, in depth-first order,
+// without descending into sub-pages
+func forEachHeader(blocks []*Block, cb func(*Block)) {
+	for _, b := range blocks {
+		switch b.Type {
+		case BlockHeader, BlockSubHeader, BlockSubSubHeader:
+			cb(b)
+		case BlockPage, BlockCollectionViewPage:
+			continue
+		}
+		forEachHeader(b.Content, cb)
+	}
+}
+
+// markdownAnchor returns the anchor GitHub generates for a header
+func markdownAnchor(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == ' ' || r == '-':
+			sb.WriteRune('-')
+		case r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r > 127:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func (c *markdownConverter) tableCell(block *Block, spans []*TextSpan) string {
+	s := c.textSpans(block, spans)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\n", "<br>")
+	return s
+}
+
+// tableView converts a table view to GFM table
+func (c *markdownConverter) tableView(tv *TableView) string {
+	if len(tv.Columns) == 0 {
+		return ""
+	}
+	var lines []string
+	var header, sep []string
+	for _, col := range tv.Columns {
+		header = append(header, strings.ReplaceAll(c.escape(col.Name()), "|", `\|`))
+		sep = append(sep, "---")
+	}
+	lines = append(lines, "| "+strings.Join(header, " | ")+" |")
+	lines = append(lines, "| "+strings.Join(sep, " | ")+" |")
+	for _, row := range tv.Rows {
+		var cells []string
+		for _, col := range tv.Columns {
+			cells = append(cells, c.tableCell(row.Page, row.Page.GetProperty(col.ID())))
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (c *markdownConverter) tableViews(block *Block) string {
+	var parts []string
+	for _, tv := range block.TableViews {
+		if s := c.tableView(tv); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func (c *markdownConverter) block(block *Block, listNo int) string {
+	switch block.Type {
+	case BlockText:
+		return c.withChildren(c.inline(block), block, "")
+	case BlockHeader:
+		return c.withChildren("# "+c.inline(block), block, "")
+	case BlockSubHeader:
+		return c.withChildren("## "+c.inline(block), block, "")
+	case BlockSubSubHeader:
+		return c.withChildren("### "+c.inline(block), block, "")
+	case BlockBulletedList:
+		return c.listItem(block, "- ")
+	case BlockNumberedList:
+		return c.listItem(block, fmt.Sprintf("%d. ", listNo))
+	case BlockTodo:
+		if block.IsChecked {
+			return c.listItem(block, "- [x] ")
+		}
+		return c.listItem(block, "- [ ] ")
+	case BlockToggle:
+		s := "<details>\n<summary>" + c.inline(block) + "</summary>"
+		if children := c.blocks(block.Content); children != "" {
+			s += "\n\n" + children
+		}
+		return s + "\n\n</details>"
+	case BlockQuote:
+		return indent(c.withChildren(c.inline(block), block, ""), "> ")
+	case BlockCallout:
+		s := c.inline(block)
+		if icon := block.Format.PageIcon; icon != "" && !strings.HasPrefix(icon, "http") {
+			s = icon + " " + s
+		}
+		return indent(c.withChildren(s, block, ""), "> ")
+	case BlockCode:
+		return c.code(block)
+	case BlockEquation:
+		return "$$\n" + TextSpansToString(block.InlineContent) + "\n$$"
+	case BlockDivider:
+		return "---"
+	case BlockImage:
+		caption := TextSpansToString(block.GetCaption())
+		return fmt.Sprintf("![%s](%s)", c.escape(caption), c.rewriteURL(block, block.Source))
+	case BlockFile, BlockPDF, BlockAudio, BlockVideo:
+		title := block.Title
+		if title == "" {
+			title = TextSpansToString(block.GetCaption())
+		}
+		return c.link(block, title, block.Source)
+	case BlockBookmark:
+		s := c.link(block, block.Title, block.Link)
+		if block.Description != "" {
+			s += "\n" + c.escape(block.Description)
+		}
+		return s
+	case BlockEmbed, BlockTweet, BlockGist, BlockCodepen, BlockFigma,
+		BlockMaps, BlockDrive, BlockMiro:
+		uri := block.Source
+		if uri == "" {
+			uri = block.Link
+		}
+		if uri == "" {
+			return ""
+		}
+		return c.link(block, TextSpansToString(block.GetCaption()), uri)
+	case BlockPage:
+		return c.linkToPage(block, block.ID)
+	case BlockAlias, BlockLinkToPage:
+		return c.linkToPage(block, aliasPageID(block))
+	case BlockCollectionView, BlockLinkToCollection:
+		return c.tableViews(block)
+	case BlockCollectionViewPage:
+		return c.linkToPage(block, block.ID)
+	case BlockTableOfContents:
+		return c.toc(block)
+	case BlockColumnList, BlockColumn, BlockTransclusionReference:
+		return c.blocks(block.Content)
+	case BlockBreadcrumb, BlockComment, BlockFactory, BlockCopyIndicator:
+		// not meaningful outside of Notion
+		return ""
+	}
+	// unknown block type, output text and children, if any
+	return c.withChildren(c.inline(block), block, "")
+}
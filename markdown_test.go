@@ -0,0 +1,192 @@
+package notionapi
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+func testBlockID(n int) string {
+	return fmt.Sprintf("00000000-0000-0000-0000-%012d", n)
+}
+
+// spans builds value of a text property e.g. spans("plain", "bold", "b")
+// creates plain text followed by bold text
+func spans(textAndAttrs ...string) []interface{} {
+	var res []interface{}
+	for i := 0; i < len(textAndAttrs); i += 2 {
+		span := []interface{}{textAndAttrs[i]}
+		if attrs := textAndAttrs[i+1]; attrs != "" {
+			var a []interface{}
+			for _, attr := range strings.Split(attrs, ",") {
+				var parts []interface{}
+				for _, s := range strings.Split(attr, " ") {
+					parts = append(parts, s)
+				}
+				a = append(a, parts)
+			}
+			span = append(span, a)
+		}
+		res = append(res, span)
+	}
+	return res
+}
+
+type testBlock struct {
+	Type     string
+	Props    map[string]interface{}
+	Format   map[string]interface{}
+	Children []*testBlock
+}
+
+func addTestBlocks(p *Page, parentID string, tb *testBlock, nextID *int) *Block {
+	*nextID++
+	b := &Block{
+		ID:          testBlockID(*nextID),
+		Alive:       true,
+		Type:        tb.Type,
+		Properties:  tb.Props,
+		ParentID:    parentID,
+		ParentTable: TableBlock,
+		RawJSON:     map[string]interface{}{},
+		Page:        p,
+	}
+	if tb.Format != nil {
+		b.RawJSON["format"] = tb.Format
+	}
+	p.idToBlock[b.ID] = b
+	for _, child := range tb.Children {
+		cb := addTestBlocks(p, b.ID, child, nextID)
+		b.ContentIDs = append(b.ContentIDs, cb.ID)
+	}
+	return b
+}
+
+// newTestPage builds a resolved page from a tree of test blocks
+func newTestPage(t *testing.T, root *testBlock) *Page {
+	p := &Page{
+		idToBlock: map[string]*Block{},
+	}
+	nextID := 0
+	b := addTestBlocks(p, "", root, &nextID)
+	p.ID = b.ID
+	assert.NoError(t, p.resolveBlocks())
+	return p
+}
+
+func titleProp(textAndAttrs ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"title": spans(textAndAttrs...),
+	}
+}
+
+func TestToMarkdown(t *testing.T) {
+	root := &testBlock{
+		Type:  BlockPage,
+		Props: titleProp("My page", ""),
+		Children: []*testBlock{
+			{Type: BlockHeader, Props: titleProp("Header", "")},
+			{Type: BlockText, Props: titleProp("plain ", "", "bold ", "b", "italic", "i", " and ", "", "code", "c", " ", "", "link", "a https://blog.kowalczyk.info")},
+			{Type: BlockBulletedList, Props: titleProp("one", ""), Children: []*testBlock{
+				{Type: BlockBulletedList, Props: titleProp("nested", "")},
+			}},
+			{Type: BlockBulletedList, Props: titleProp("two", "")},
+			{Type: BlockNumberedList, Props: titleProp("first", "")},
+			{Type: BlockNumberedList, Props: titleProp("second", "")},
+			{Type: BlockTodo, Props: map[string]interface{}{
+				"title":   spans("done", ""),
+				"checked": spans("Yes", ""),
+			}},
+			{Type: BlockTodo, Props: titleProp("not done", "")},
+			{Type: BlockCode, Props: map[string]interface{}{
+				"title":    spans("fmt.Println(\"hi\")", ""),
+				"language": spans("Go", ""),
+			}},
+			{Type: BlockQuote, Props: titleProp("quoted", "")},
+			{Type: BlockDivider},
+			{Type: BlockToggle, Props: titleProp("toggle", ""), Children: []*testBlock{
+				{Type: BlockText, Props: titleProp("hidden", "")},
+			}},
+			{Type: BlockEquation, Props: titleProp("e=mc^2", "")},
+			{Type: BlockImage, Props: map[string]interface{}{
+				"source":  spans("https://example.com/img.png", ""),
+				"caption": spans("an image", ""),
+			}},
+			{Type: BlockBreadcrumb},
+		},
+	}
+	p := newTestPage(t, root)
+	opts := &MarkdownOptions{
+		RewriteURL: func(block *Block, uri string) string {
+			if block.Type == BlockImage {
+				return "img.png"
+			}
+			return uri
+		},
+	}
+	got := ToMarkdown(p, opts)
+	exp := "# My page\n\n" +
+		"# Header\n\n" +
+		"plain **bold** *italic* and `code` [link](https://blog.kowalczyk.info)\n\n" +
+		"- one\n" +
+		"  - nested\n" +
+		"- two\n" +
+		"1. first\n" +
+		"2. second\n" +
+		"- [x] done\n" +
+		"- [ ] not done\n\n" +
+		"```go\nfmt.Println(\"hi\")\n```\n\n" +
+		"> quoted\n\n" +
+		"---\n\n" +
+		"<details>\n<summary>toggle</summary>\n\nhidden\n\n</details>\n\n" +
+		"$$\ne=mc^2\n$$\n\n" +
+		"![an image](img.png)\n"
+	assert.Equal(t, exp, got)
+}
+
+func TestToMarkdownMentions(t *testing.T) {
+	root := &testBlock{
+		Type:  BlockPage,
+		Props: titleProp("Page", ""),
+		Children: []*testBlock{
+			{Type: BlockPage, Props: titleProp("Sub page", "")},
+			{Type: BlockText, Props: titleProp("see ", "", TextSpanSpecial, "p "+testBlockID(2), " by ", "", TextSpanSpecial, "u some-user-id")},
+		},
+	}
+	p := newTestPage(t, root)
+	got := ToMarkdown(p, nil)
+	link := "[Sub page](https://www.notion.so/" + ToNoDashID(testBlockID(2)) + ")"
+	exp := "# Page\n\n" + link + "\n\nsee " + link + " by @some-user-id\n"
+	assert.Equal(t, exp, got)
+}
+
+func TestMarkdownTable(t *testing.T) {
+	p := newTestPage(t, &testBlock{Type: BlockPage, Props: titleProp("Page", "")})
+	row := &Block{
+		Type: BlockPage,
+		Properties: map[string]interface{}{
+			"title": spans("Row | 1", ""),
+			"abcd":  spans("Yes", ""),
+		},
+	}
+	tv := &TableView{
+		Columns: []*ColumnInfo{
+			{Property: &TableProperty{Property: "title"}, Schema: &ColumnSchema{Name: "Name", Type: ColumnTypeTitle}},
+			{Property: &TableProperty{Property: "abcd"}, Schema: &ColumnSchema{Name: "Done", Type: ColumnTypeCheckbox}},
+		},
+	}
+	tv.Rows = []*TableRow{{TableView: tv, Page: row}}
+	c := &markdownConverter{page: p, opts: &MarkdownOptions{}}
+	exp := "| Name | Done |\n| --- | --- |\n| Row \\| 1 | Yes |"
+	assert.Equal(t, exp, c.tableView(tv))
+}
+
+func TestMarkdownFromCache(t *testing.T) {
+	p := testDownloadFromCache(t, "6682351e44bb4f9ca0e149b703265bdb")
+	md := ToMarkdown(p, nil)
+	assert.True(t, strings.HasPrefix(md, "# Test headers\n\n"))
+	assert.True(t, strings.Contains(md, "\n## "))
+	assert.True(t, strings.Contains(md, "\n### "))
+}
@@ -1,6 +1,7 @@
 package notionapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
@@ -97,14 +98,24 @@ func (p *Page) Root() *Block {
 
 // SetTitle changes page title
 func (p *Page) SetTitle(s string) error {
+	return p.SetTitleCtx(context.Background(), s)
+}
+
+// SetTitleCtx is like SetTitle but can be cancelled with ctx
+func (p *Page) SetTitleCtx(ctx context.Context, s string) error {
 	op := p.Root().SetTitleOp(s)
 	ops := []*Operation{op}
-	return p.client.SubmitTransaction(ops)
+	return p.client.SubmitTransactionCtx(ctx, ops)
 }
 
 // SetFormat changes format properties of a page. Valid values are:
 // page_full_width (bool), page_small_text (bool)
 func (p *Page) SetFormat(args map[string]interface{}) error {
+	return p.SetFormatCtx(context.Background(), args)
+}
+
+// SetFormatCtx is like SetFormat but can be cancelled with ctx
+func (p *Page) SetFormatCtx(ctx context.Context, args map[string]interface{}) error {
 	if len(args) == 0 {
 		return errors.New("args can't be empty")
 	}
@@ -115,7 +126,7 @@ func (p *Page) SetFormat(args map[string]interface{}) error {
 	}
 	op := p.Root().UpdateFormatOp(args)
 	ops := []*Operation{op}
-	return p.client.SubmitTransaction(ops)
+	return p.client.SubmitTransactionCtx(ctx, ops)
 }
 
 // NotionURL returns url of this page on notion.so
@@ -0,0 +1,419 @@
+package notionapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Filter is a filter of collection rows for QueryCollectionWith.
+// Build it with Prop, And and Or e.g.
+//
+//	And(Prop("Status").Is("Done"), Prop("Due").Before(time.Now()))
+type Filter interface {
+	// toQuery returns the filter in the format used by Notion
+	// in query2, with column names resolved to ids
+	toQuery(collection *Collection) (map[string]interface{}, error)
+}
+
+type filterGroup struct {
+	operator string
+	filters  []Filter
+}
+
+func (g *filterGroup) toQuery(collection *Collection) (map[string]interface{}, error) {
+	filters := []interface{}{}
+	for _, f := range g.filters {
+		v, err := f.toQuery(collection)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, v)
+	}
+	return map[string]interface{}{
+		"operator": g.operator,
+		"filters":  filters,
+	}, nil
+}
+
+// And returns a filter that matches rows matched by all filters
+func And(filters ...Filter) Filter {
+	return &filterGroup{operator: "and", filters: filters}
+}
+
+// Or returns a filter that matches rows matched by any of filters
+func Or(filters ...Filter) Filter {
+	return &filterGroup{operator: "or", filters: filters}
+}
+
+// PropFilter creates filters for a column (property) of a collection
+type PropFilter struct {
+	name string
+}
+
+// Prop starts a filter for a column with a given name (or id)
+func Prop(name string) *PropFilter {
+	return &PropFilter{name: name}
+}
+
+// conditions used in propCondition. Actual operator depends on column type
+const (
+	condIs             = "is"
+	condIsNot          = "is_not"
+	condContains       = "contains"
+	condDoesNotContain = "does_not_contain"
+	condStartsWith     = "starts_with"
+	condEndsWith       = "ends_with"
+	condIsEmpty        = "is_empty"
+	condIsNotEmpty     = "is_not_empty"
+	condGreater        = "greater_than"
+	condLess           = "less_than"
+	condGreaterOrEqual = "greater_than_or_equal_to"
+	condLessOrEqual    = "less_than_or_equal_to"
+	condBefore         = "is_before"
+	condAfter          = "is_after"
+	condOnOrBefore     = "is_on_or_before"
+	condOnOrAfter      = "is_on_or_after"
+)
+
+type propCondition struct {
+	name  string
+	cond  string
+	value interface{}
+}
+
+func (p *PropFilter) cond(cond string, value interface{}) Filter {
+	return &propCondition{name: p.name, cond: cond, value: value}
+}
+
+// Is matches rows where the column is equal to v: a string for text
+// and select columns, a number, a bool for checkbox, a date
+// (*Date, time.Time or relative e.g. "today") or an id of a user / page
+func (p *PropFilter) Is(v interface{}) Filter { return p.cond(condIs, v) }
+
+// IsNot is a negation of Is
+func (p *PropFilter) IsNot(v interface{}) Filter { return p.cond(condIsNot, v) }
+
+// Contains matches text columns containing s, multi-select columns
+// with option s and person / relation columns with id s
+func (p *PropFilter) Contains(s string) Filter { return p.cond(condContains, s) }
+
+// DoesNotContain is a negation of Contains
+func (p *PropFilter) DoesNotContain(s string) Filter { return p.cond(condDoesNotContain, s) }
+
+// StartsWith matches text columns that start with s
+func (p *PropFilter) StartsWith(s string) Filter { return p.cond(condStartsWith, s) }
+
+// EndsWith matches text columns that end with s
+func (p *PropFilter) EndsWith(s string) Filter { return p.cond(condEndsWith, s) }
+
+// IsEmpty matches rows where the column is not set
+func (p *PropFilter) IsEmpty() Filter { return p.cond(condIsEmpty, nil) }
+
+// IsNotEmpty matches rows where the column is set
+func (p *PropFilter) IsNotEmpty() Filter { return p.cond(condIsNotEmpty, nil) }
+
+// GreaterThan matches number columns > n
+func (p *PropFilter) GreaterThan(n float64) Filter { return p.cond(condGreater, n) }
+
+// LessThan matches number columns < n
+func (p *PropFilter) LessThan(n float64) Filter { return p.cond(condLess, n) }
+
+// GreaterThanOrEqual matches number columns >= n
+func (p *PropFilter) GreaterThanOrEqual(n float64) Filter { return p.cond(condGreaterOrEqual, n) }
+
+// LessThanOrEqual matches number columns <= n
+func (p *PropFilter) LessThanOrEqual(n float64) Filter { return p.cond(condLessOrEqual, n) }
+
+// Before matches date columns before d (*Date, time.Time or relative e.g. "today")
+func (p *PropFilter) Before(d interface{}) Filter { return p.cond(condBefore, d) }
+
+// After matches date columns after d
+func (p *PropFilter) After(d interface{}) Filter { return p.cond(condAfter, d) }
+
+// OnOrBefore matches date columns on or before d
+func (p *PropFilter) OnOrBefore(d interface{}) Filter { return p.cond(condOnOrBefore, d) }
+
+// OnOrAfter matches date columns on or after d
+func (p *PropFilter) OnOrAfter(d interface{}) Filter { return p.cond(condOnOrAfter, d) }
+
+// IsChecked matches checkbox columns that are checked
+func (p *PropFilter) IsChecked() Filter { return p.cond(condIs, true) }
+
+// IsNotChecked matches checkbox columns that are not checked
+func (p *PropFilter) IsNotChecked() Filter { return p.cond(condIs, false) }
+
+// filterKind returns the kind of filter operators (e.g. "string" for
+// "string_contains") for a column
+func filterKind(schema *ColumnSchema) string {
+	typ := schema.Type
+	if typ == ColumnTypeFormula && schema.Formula != nil {
+		typ = schema.Formula.ResultType
+	}
+	switch typ {
+	case ColumnTypeTitle, ColumnTypeText, ColumnTypeURL, ColumnTypeEmail, ColumnTypePhoneNumber:
+		return "string"
+	case ColumnTypeNumber:
+		return "number"
+	case ColumnTypeCheckbox:
+		return "checkbox"
+	case ColumnTypeSelect, ColumnTypeMultiSelect:
+		return "enum"
+	case ColumnTypeDate, ColumnTypeCreatedTime, ColumnTypeLastEditedTime:
+		return "date"
+	case ColumnTypePerson, ColumnTypeCreatedBy, ColumnTypeLastEditedBy:
+		return "person"
+	case ColumnTypeRelation:
+		return "relation"
+	}
+	return ""
+}
+
+// conditions supported by each kind of column
+var filterKindConditions = map[string][]string{
+	"string":   {condIs, condIsNot, condContains, condDoesNotContain, condStartsWith, condEndsWith},
+	"number":   {condIs, condIsNot, condGreater, condLess, condGreaterOrEqual, condLessOrEqual},
+	"checkbox": {condIs, condIsNot},
+	"enum":     {condIs, condIsNot, condContains, condDoesNotContain},
+	"date":     {condIs, condBefore, condAfter, condOnOrBefore, condOnOrAfter},
+	"person":   {condIs, condIsNot, condContains, condDoesNotContain},
+	"relation": {condIs, condIsNot, condContains, condDoesNotContain},
+}
+
+// filterOperator returns Notion operator for a condition on a kind of column
+func filterOperator(kind string, cond string) string {
+	switch kind {
+	case "number":
+		switch cond {
+		case condIs:
+			return "number_equals"
+		case condIsNot:
+			return "number_does_not_equal"
+		}
+	case "date":
+		if cond == condIs {
+			return "date_is"
+		}
+	case "person", "relation":
+		// person and relation can have many values so is means contains
+		switch cond {
+		case condIs:
+			cond = condContains
+		case condIsNot:
+			cond = condDoesNotContain
+		}
+	}
+	return kind + "_" + cond
+}
+
+func dateFilterValue(v interface{}) (map[string]interface{}, error) {
+	var d *Date
+	switch dv := v.(type) {
+	case string:
+		// e.g. "today", "tomorrow", "one_week_ago"
+		return map[string]interface{}{"type": "relative", "value": dv}, nil
+	case *Date:
+		d = dv
+	case Date:
+		d = &dv
+	case time.Time:
+		d = timeToDate(dv)
+	default:
+		return nil, fmt.Errorf("expected *Date, time.Time or string, got %T", v)
+	}
+	value := map[string]interface{}{
+		"type":       d.Type,
+		"start_date": d.StartDate,
+	}
+	if d.StartTime != "" {
+		value["start_time"] = d.StartTime
+	}
+	if d.EndDate != "" {
+		value["end_date"] = d.EndDate
+	}
+	if d.EndTime != "" {
+		value["end_time"] = d.EndTime
+	}
+	if d.TimeZone != nil {
+		value["time_zone"] = *d.TimeZone
+	}
+	return map[string]interface{}{"type": "exact", "value": value}, nil
+}
+
+func numberFilterValue(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case float32:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", v)
+}
+
+// filterValue returns value of a filter for a kind of column
+func filterValue(kind string, v interface{}) (interface{}, error) {
+	switch kind {
+	case "date":
+		return dateFilterValue(v)
+	case "number":
+		n, err := numberFilterValue(v)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "exact", "value": n}, nil
+	case "checkbox":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool, got %T", v)
+		}
+		return map[string]interface{}{"type": "exact", "value": b}, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected string, got %T", v)
+	}
+	if kind == "relation" {
+		s = ToDashID(s)
+	}
+	return map[string]interface{}{"type": "exact", "value": s}, nil
+}
+
+func (c *propCondition) toQuery(collection *Collection) (map[string]interface{}, error) {
+	colID, schema, err := collection.findColumn(c.name)
+	if err != nil {
+		return nil, err
+	}
+	filter := map[string]interface{}{}
+	switch c.cond {
+	case condIsEmpty, condIsNotEmpty:
+		filter["operator"] = c.cond
+	default:
+		kind := filterKind(schema)
+		supported := false
+		for _, cond := range filterKindConditions[kind] {
+			supported = supported || cond == c.cond
+		}
+		if !supported {
+			return nil, fmt.Errorf("column '%s' of type '%s' can't be filtered with '%s'", schema.Name, schema.Type, c.cond)
+		}
+		v, err := filterValue(kind, c.value)
+		if err != nil {
+			return nil, fmt.Errorf("column '%s': %w", schema.Name, err)
+		}
+		filter["operator"] = filterOperator(kind, c.cond)
+		filter["value"] = v
+	}
+	return map[string]interface{}{
+		"property": colID,
+		"filter":   filter,
+	}, nil
+}
+
+// SortSpec describes sorting by a column, see Asc and Desc
+type SortSpec struct {
+	// Column is a name (or id) of a column
+	Column     string
+	Descending bool
+}
+
+// Asc returns ascending sort by column with a given name
+func Asc(column string) SortSpec {
+	return SortSpec{Column: column}
+}
+
+// Desc returns descending sort by column with a given name
+func Desc(column string) SortSpec {
+	return SortSpec{Column: column, Descending: true}
+}
+
+// BuildQuery returns Query (for QueryCollection or MakeLoaderReducer) with a
+// filter and sorts, resolving column names through collection.Schema.
+// filter can be nil
+func BuildQuery(collection *Collection, filter Filter, sorts []SortSpec) (*Query, error) {
+	if collection == nil {
+		return nil, errors.New("collection is nil")
+	}
+	query := &Query{}
+	if filter != nil {
+		// top-level filter must be a group
+		if _, ok := filter.(*filterGroup); !ok {
+			filter = And(filter)
+		}
+		f, err := filter.toQuery(collection)
+		if err != nil {
+			return nil, err
+		}
+		query.Filter = f
+	}
+	for _, s := range sorts {
+		colID, _, err := collection.findColumn(s.Column)
+		if err != nil {
+			return nil, err
+		}
+		direction := "ascending"
+		if s.Descending {
+			direction = "descending"
+		}
+		query.Sort = append(query.Sort, QuerySort{
+			Property:  colID,
+			Direction: direction,
+		})
+	}
+	return query, nil
+}
+
+// QueryCollectionWith returns rows of a collection view matching the filter, sorted
+// by sorts (instead of filter and sorts of the view). filter can be nil.
+// limit is the max number of rows and 0 uses the server default.
+// RowLimitAll returns all rows: rows beyond what the server returns in
+// a single query are fetched by id, like in FetchAllTableRows, which
+// requires auth token.
+//
+// The returned TableView is not part of a page so its Page is nil
+func (c *Client) QueryCollectionWith(collection *Collection, view *CollectionView, filter Filter, sorts []SortSpec, limit int) (*TableView, error) {
+	return c.QueryCollectionWithCtx(context.Background(), collection, view, filter, sorts, limit)
+}
+
+// QueryCollectionWithCtx is like QueryCollectionWith but can be cancelled with ctx
+func (c *Client) QueryCollectionWithCtx(ctx context.Context, collection *Collection, view *CollectionView, filter Filter, sorts []SortSpec, limit int) (*TableView, error) {
+	if view == nil {
+		return nil, errors.New("collection view is nil")
+	}
+	query, err := BuildQuery(collection, filter, sorts)
+	if err != nil {
+		return nil, err
+	}
+	spaceID := view.SpaceID
+	if collection.SpaceId != nil {
+		spaceID = *collection.SpaceId
+	}
+	req := QueryCollectionRequest{}
+	req.Collection.ID = collection.ID
+	req.Collection.SpaceID = spaceID
+	req.CollectionView.ID = view.ID
+	req.CollectionView.SpaceID = spaceID
+	rsp, err := c.queryCollectionWithLimit(ctx, req, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	tv := &TableView{
+		Collection:     collection,
+		CollectionView: view,
+		SpaceId:        spaceID,
+		SizeHint:       rsp.Result.SizeHint,
+	}
+	if err = c.buildTableView(tv, rsp); err != nil {
+		return nil, err
+	}
+	if limit == RowLimitAll {
+		if err = c.loadAllTableRows(ctx, tv); err != nil {
+			return nil, err
+		}
+	}
+	return tv, nil
+}
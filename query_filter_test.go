@@ -0,0 +1,136 @@
+package notionapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kjk/common/assert"
+)
+
+func TestBuildQuery(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	filter := And(
+		Prop("Status").Is("Done"),
+		Prop("Due").Before(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)),
+		Or(
+			Prop("Name").Contains("foo"),
+			Prop("Price").GreaterThanOrEqual(10),
+			Prop("Link").IsEmpty(),
+		),
+		Prop("Done").IsChecked(),
+		Prop("Related").Is(ToNoDashID(testBlockID(7))),
+		Prop("Due").OnOrAfter("today"),
+	)
+	query, err := BuildQuery(collection, filter, []SortSpec{Desc("Due"), Asc("Name")})
+	assert.NoError(t, err)
+
+	exact := func(v interface{}) interface{} {
+		return map[string]interface{}{"type": "exact", "value": v}
+	}
+	prop := func(id string, op string, value interface{}) interface{} {
+		filter := map[string]interface{}{"operator": op}
+		if value != nil {
+			filter["value"] = value
+		}
+		return map[string]interface{}{"property": id, "filter": filter}
+	}
+	exp := map[string]interface{}{
+		"operator": "and",
+		"filters": []interface{}{
+			prop("slct", "enum_is", exact("Done")),
+			prop("date", "date_is_before", exact(map[string]interface{}{"type": "date", "start_date": "2021-03-04"})),
+			map[string]interface{}{
+				"operator": "or",
+				"filters": []interface{}{
+					prop("title", "string_contains", exact("foo")),
+					prop("nmbr", "number_greater_than_or_equal_to", exact(10.0)),
+					prop("url_", "is_empty", nil),
+				},
+			},
+			prop("AbCd", "checkbox_is", exact(true)),
+			prop("rltn", "relation_contains", exact(testBlockID(7))),
+			prop("date", "date_is_on_or_after", map[string]interface{}{"type": "relative", "value": "today"}),
+		},
+	}
+	assert.Equal(t, exp, jsonRoundTrip(t, query.Filter))
+	expSort := []QuerySort{
+		{Property: "date", Direction: "descending"},
+		{Property: "title", Direction: "ascending"},
+	}
+	assert.Equal(t, expSort, query.Sort)
+
+	// a single filter is wrapped in a group
+	query, err = BuildQuery(collection, Prop("Price").Is(3), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "and", query.Filter["operator"])
+
+	query, err = BuildQuery(collection, nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, query.Filter)
+}
+
+func TestBuildQueryErrors(t *testing.T) {
+	collection := newTestTableRow().TableView.Collection
+	filters := []Filter{
+		Prop("Missing").Is("x"),
+		Prop("Price").Contains("x"),
+		Prop("Price").Is("x"),
+		Prop("Name").GreaterThan(3),
+		Prop("Done").Is("yes"),
+		Prop("Due").Is(5),
+		Or(Prop("Status").StartsWith("x")),
+	}
+	for _, f := range filters {
+		_, err := BuildQuery(collection, f, nil)
+		assert.Error(t, err)
+	}
+	_, err := BuildQuery(collection, nil, []SortSpec{Asc("Missing")})
+	assert.Error(t, err)
+}
+
+func TestQueryCollectionWith(t *testing.T) {
+	var req QueryCollectionRequest
+	nRequested := 0
+	fake := fakeCollectionServer(3, 3, &nRequested)
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			assert.NoError(t, jsonit.Unmarshal(body, &req))
+			return fake(ctx, uri, body, headers...)
+		},
+	}
+	tv := newFakeTableView()
+	tv.Collection.Schema = newTestTableRow().TableView.Collection.Schema
+	tv.CollectionView.SpaceID = testSpaceID
+	res, err := c.QueryCollectionWith(tv.Collection, tv.CollectionView, Prop("Done").IsChecked(), []SortSpec{Asc("Name")}, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, res.RowCount())
+	assert.Equal(t, testSpaceID, req.Collection.SpaceID)
+
+	loader := req.Loader.(map[string]interface{})
+	assert.Equal(t, "and", loader["filter"].(map[string]interface{})["operator"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"id": "", "type": "", "property": "title", "direction": "ascending",
+	}}, loader["sort"])
+	limit := loader["reducers"].(map[string]interface{})[ReducerCollectionGroupResultsName].(map[string]interface{})["limit"]
+	assert.Equal(t, 100.0, limit)
+}
+
+func TestQueryCollectionWithAllRows(t *testing.T) {
+	nRequested := 0
+	c := &Client{
+		AuthToken:        "token",
+		httpPostOverride: fakeCollectionServer(1200, 100, &nRequested),
+	}
+	tv := newFakeTableView()
+	tv.Collection.Schema = newTestTableRow().TableView.Collection.Schema
+	res, err := c.QueryCollectionWith(tv.Collection, tv.CollectionView, nil, nil, RowLimitAll)
+	assert.NoError(t, err)
+	assert.Equal(t, 1200, res.RowCount())
+	assert.Equal(t, 1100, nRequested)
+	assert.Nil(t, res.Page)
+	for i, tr := range res.Rows {
+		assert.Equal(t, res.RowIds[i], tr.Page.ID)
+	}
+}
@@ -0,0 +1,125 @@
+package notionapi
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinRequestDelay = time.Millisecond * 360
+)
+
+// RateLimiter limits how often requests are sent to Notion server.
+// Implementations must be safe for concurrent use because
+// a single RateLimiter can be shared by multiple Clients
+// e.g. when they access the same workspace
+type RateLimiter interface {
+	// Wait blocks until the next request can be sent or ctx is done.
+	// Returns ctx.Err() if ctx was cancelled while waiting
+	Wait(ctx context.Context) error
+}
+
+// TokenBucketLimiter is a RateLimiter that allows bursts of up to
+// burst requests and adds a token every interval
+type TokenBucketLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu sync.Mutex
+	// can go negative when there are goroutines waiting for a token
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucketLimiter returns a limiter that allows, on average, one
+// request per interval with bursts of up to burst requests
+func NewTokenBucketLimiter(interval time.Duration, burst int) *TokenBucketLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucketLimiter{
+		interval: interval,
+		burst:    burst,
+		tokens:   float64(burst),
+	}
+}
+
+// must be called with l.mu locked
+func (l *TokenBucketLimiter) refill(now time.Time) {
+	if !l.last.IsZero() && l.interval > 0 {
+		elapsed := now.Sub(l.last)
+		l.tokens += float64(elapsed) / float64(l.interval)
+		if l.tokens > float64(l.burst) {
+			l.tokens = float64(l.burst)
+		}
+	}
+	l.last = now
+}
+
+// Wait implements RateLimiter
+func (l *TokenBucketLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l.interval <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	l.refill(time.Now())
+	// reserve a token. If we don't have one, we wait until it's replenished
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens * float64(l.interval))
+	}
+	l.mu.Unlock()
+
+	if err := sleepCtx(ctx, wait); err != nil {
+		// give back the reservation so that others don't wait for us
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// lazyRateLimiter is a TokenBucketLimiter created on first use, because
+// MinRequestDelay and RateLimitBurst can be set after NewClient
+type lazyRateLimiter struct {
+	once    sync.Once
+	limiter *TokenBucketLimiter
+}
+
+// rateLimiterSettings identifies limiters shared by Clients
+// not created with NewClient
+type rateLimiterSettings struct {
+	minDelay time.Duration
+	burst    int
+}
+
+// maps rateLimiterSettings to *TokenBucketLimiter
+var sharedRateLimiters sync.Map
+
+func (c *Client) getRateLimiter() RateLimiter {
+	if c.RateLimiter != nil {
+		return c.RateLimiter
+	}
+	minDelay := c.MinRequestDelay
+	if minDelay == 0 {
+		minDelay = defaultMinRequestDelay
+	}
+	if l := c.defaultRateLimiter; l != nil {
+		l.once.Do(func() {
+			l.limiter = NewTokenBucketLimiter(minDelay, c.RateLimitBurst)
+		})
+		return l.limiter
+	}
+	settings := rateLimiterSettings{minDelay: minDelay, burst: c.RateLimitBurst}
+	if l, ok := sharedRateLimiters.Load(settings); ok {
+		return l.(*TokenBucketLimiter)
+	}
+	l, _ := sharedRateLimiters.LoadOrStore(settings, NewTokenBucketLimiter(minDelay, c.RateLimitBurst))
+	return l.(*TokenBucketLimiter)
+}
@@ -0,0 +1,66 @@
+package notionapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kjk/common/assert"
+)
+
+func TestTokenBucketLimiterBurst(t *testing.T) {
+	l := NewTokenBucketLimiter(time.Millisecond*50, 3)
+	ctx := context.Background()
+	timeStart := time.Now()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.Wait(ctx))
+	}
+	assert.True(t, time.Since(timeStart) < time.Millisecond*40)
+	assert.NoError(t, l.Wait(ctx))
+	assert.True(t, time.Since(timeStart) >= time.Millisecond*40)
+}
+
+func TestTokenBucketLimiterCancel(t *testing.T) {
+	l := NewTokenBucketLimiter(time.Hour, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	assert.NoError(t, l.Wait(ctx))
+	err := l.Wait(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestTokenBucketLimiterConcurrent(t *testing.T) {
+	l := NewTokenBucketLimiter(time.Millisecond*10, 1)
+	ctx := context.Background()
+	timeStart := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = l.Wait(ctx)
+		}()
+	}
+	wg.Wait()
+	// first request goes immediately, the other 4 have to wait 10 ms each
+	assert.True(t, time.Since(timeStart) >= time.Millisecond*35)
+}
+
+func TestClientSharesDefaultRateLimiter(t *testing.T) {
+	// copies made before the first request share the limiter
+	c := NewClient()
+	c.MinRequestDelay = time.Millisecond * 5
+	c2 := *c
+	rl := c.getRateLimiter()
+	assert.True(t, rl == c2.getRateLimiter())
+	assert.Equal(t, time.Millisecond*5, rl.(*TokenBucketLimiter).interval)
+	assert.False(t, rl == NewClient().getRateLimiter())
+
+	// Clients not created with NewClient share limiters with the same settings
+	c3 := &Client{}
+	c4 := &Client{}
+	assert.True(t, c3.getRateLimiter() == c4.getRateLimiter())
+	assert.False(t, c3.getRateLimiter() == (&Client{RateLimitBurst: 3}).getRateLimiter())
+}
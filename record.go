@@ -100,5 +100,13 @@ func parseRecord(table string, r *Record) error {
 	if err := jsonit.Unmarshal(r.Value, &obj); err != nil {
 		return err
 	}
+	if r.Collection != nil {
+		// deleted columns are null in schema
+		for colID, schema := range r.Collection.Schema {
+			if schema == nil {
+				delete(r.Collection.Schema, colID)
+			}
+		}
+	}
 	return nil
 }
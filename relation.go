@@ -0,0 +1,368 @@
+package notionapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ResolveRelations loads pages referred to by ColumnTypeRelation columns
+// of rows in tv (and collections they belong to) so that TableRow.Related
+// and TableRow.Rollup can be used. Pages we don't have access to are skipped.
+// Only pages that were not loaded by previous calls are fetched
+func (c *Client) ResolveRelations(tv *TableView) error {
+	return c.ResolveRelationsCtx(context.Background(), tv)
+}
+
+// ResolveRelationsCtx is like ResolveRelations but can be cancelled with ctx
+func (c *Client) ResolveRelationsCtx(ctx context.Context, tv *TableView) error {
+	if tv == nil || tv.Collection == nil {
+		return errors.New("tableView doesn't have a collection")
+	}
+	if tv.relatedPages == nil {
+		tv.relatedPages = map[string]*Block{}
+	}
+	if tv.relatedCollections == nil {
+		tv.relatedCollections = map[string]*Collection{}
+	}
+
+	var pageIDs, collectionIDs []string
+	seen := map[string]bool{}
+	for colID, schema := range tv.Collection.Schema {
+		if schema.Type != ColumnTypeRelation {
+			continue
+		}
+		if id := ToDashID(schema.CollectionID); id != "" && !seen[id] {
+			seen[id] = true
+			if tv.relatedCollections[id] == nil {
+				collectionIDs = append(collectionIDs, id)
+			}
+		}
+		for _, tr := range tv.Rows {
+			v, err := tr.Get(colID)
+			if err != nil {
+				return err
+			}
+			for _, id := range v.AsPageIDs() {
+				id = ToDashID(id)
+				if _, loaded := tv.relatedPages[id]; !seen[id] && !loaded {
+					seen[id] = true
+					pageIDs = append(pageIDs, id)
+				}
+			}
+		}
+	}
+
+	if len(collectionIDs) > 0 {
+		collections, err := c.GetCollectionRecordsCtx(ctx, collectionIDs)
+		if err != nil {
+			return err
+		}
+		for _, collection := range collections {
+			if collection != nil {
+				tv.relatedCollections[collection.ID] = collection
+			}
+		}
+	}
+
+	c.vlogf("ResolveRelations: fetching %d related pages of collection '%s'\n", len(pageIDs), tv.Collection.ID)
+	for len(pageIDs) > 0 {
+		toGet := pageIDs
+		if len(toGet) > fetchTableRowsBatchSize {
+			toGet = pageIDs[:fetchTableRowsBatchSize]
+		}
+		pageIDs = pageIDs[len(toGet):]
+
+		blocks, err := c.GetBlockRecordsCtx(ctx, toGet)
+		if err != nil {
+			return err
+		}
+		// pages we don't have access to are nil. We remember them
+		// so that they're not requested again
+		for i, b := range blocks {
+			tv.relatedPages[toGet[i]] = b
+		}
+	}
+	return nil
+}
+
+// Related returns pages referred to by ColumnTypeRelation column with a
+// given name (or id). Returns nil if the column is not a relation or
+// relations were not loaded with Client.ResolveRelations
+func (r *TableRow) Related(columnName string) []*Block {
+	tv := r.TableView
+	if tv == nil || tv.Collection == nil || tv.relatedPages == nil {
+		return nil
+	}
+	colID, schema, err := tv.Collection.findColumn(columnName)
+	if err != nil || schema.Type != ColumnTypeRelation {
+		return nil
+	}
+	v, err := r.Get(colID)
+	if err != nil {
+		return nil
+	}
+	var res []*Block
+	for _, id := range v.AsPageIDs() {
+		if b := tv.relatedPages[ToDashID(id)]; b != nil {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// Rollup calculates the value of ColumnTypeRollup column with a given name
+// (or id) from pages loaded with Client.ResolveRelations.
+// Depending on ColumnSchema.Aggregation the value is:
+// - []*CellValue for "show_original" (values of target property; for
+// formula and rollup targets Spans hold the calculated value)
+// - float64 for "count", "count_values", "unique", "empty", "not_empty",
+// "sum", "average", "median", "min", "max", "range", "checked",
+// "unchecked" and "date_range" (in days)
+// - float64 between 0 and 1 for "percent_empty", "percent_not_empty",
+// "percent_checked" and "percent_unchecked"
+// - time.Time for "earliest_date" and "latest_date"
+// - nil if there are no values to aggregate
+func (r *TableRow) Rollup(columnName string) (interface{}, error) {
+	tv := r.TableView
+	if tv == nil || tv.Collection == nil {
+		return nil, errors.New("row doesn't have a collection")
+	}
+	_, schema, err := tv.Collection.findColumn(columnName)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Type != ColumnTypeRollup {
+		return nil, fmt.Errorf("column '%s' is not a rollup", schema.Name)
+	}
+	rows, targetID, targetSchema, err := r.rollupRows(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if schema.Aggregation == "" || schema.Aggregation == "show_original" {
+		var res []*CellValue
+		for _, tr := range rows {
+			v, err := tr.Get(targetID)
+			if err != nil {
+				return nil, err
+			}
+			if targetSchema.Type == ColumnTypeFormula || targetSchema.Type == ColumnTypeRollup {
+				calculated, err := newFormulaEvaluator(tr).evalProperty(targetID)
+				if err != nil {
+					return nil, err
+				}
+				v.Spans = nil
+				if s := FormatFormulaValue(calculated); s != "" {
+					v.Spans = []*TextSpan{{Text: s}}
+				}
+			}
+			res = append(res, v)
+		}
+		return res, nil
+	}
+
+	var values []interface{}
+	for _, tr := range rows {
+		v, err := newFormulaEvaluator(tr).evalProperty(targetID)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := v.(string); ok && targetSchema.Type == ColumnTypeMultiSelect {
+			// each option is a separate value
+			for _, part := range strings.Split(s, ",") {
+				values = append(values, part)
+			}
+			continue
+		}
+		values = append(values, v)
+	}
+	v, err := aggregateRollup(schema.Aggregation, values)
+	if err != nil {
+		return nil, fmt.Errorf("column '%s': %w", schema.Name, err)
+	}
+	return v, nil
+}
+
+// rollupRows returns related pages of a rollup column as rows of their
+// collection, with id and schema of the target property
+func (r *TableRow) rollupRows(schema *ColumnSchema) ([]*TableRow, string, *ColumnSchema, error) {
+	tv := r.TableView
+	if tv.relatedPages == nil {
+		return nil, "", nil, errors.New("relations are not resolved, call Client.ResolveRelations first")
+	}
+	relID, relSchema, err := tv.Collection.findColumn(schema.RelationProperty)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if relSchema.Type != ColumnTypeRelation {
+		return nil, "", nil, fmt.Errorf("column '%s' of rollup '%s' is not a relation", relSchema.Name, schema.Name)
+	}
+	target := tv.relatedCollections[ToDashID(relSchema.CollectionID)]
+	if target == nil {
+		return nil, "", nil, fmt.Errorf("collection '%s' of relation '%s' is not loaded", relSchema.CollectionID, relSchema.Name)
+	}
+	targetID, targetSchema, err := target.findColumn(schema.TargetProperty)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	targetTV := &TableView{
+		Collection:         target,
+		relatedPages:       tv.relatedPages,
+		relatedCollections: tv.relatedCollections,
+	}
+	var rows []*TableRow
+	for _, b := range r.Related(relID) {
+		rows = append(rows, &TableRow{TableView: targetTV, Page: b})
+	}
+	return rows, targetID, targetSchema, nil
+}
+
+func isRollupValueEmpty(v interface{}) bool {
+	return v == nil || v == ""
+}
+
+// aggregateRollup calculates aggregation of values as returned
+// by formulaEvaluator.evalProperty
+func aggregateRollup(aggregation string, values []interface{}) (interface{}, error) {
+	var nonEmpty []interface{}
+	for _, v := range values {
+		if !isRollupValueEmpty(v) {
+			nonEmpty = append(nonEmpty, v)
+		}
+	}
+	fraction := func(n int) float64 {
+		if len(values) == 0 {
+			return 0
+		}
+		return float64(n) / float64(len(values))
+	}
+	numbers := func() ([]float64, error) {
+		var res []float64
+		for _, v := range nonEmpty {
+			f, err := formulaNumber(v)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, f)
+		}
+		sort.Float64s(res)
+		return res, nil
+	}
+	nChecked := func() (int, error) {
+		n := 0
+		for _, v := range values {
+			b, err := formulaBool(v)
+			if err != nil {
+				return 0, err
+			}
+			if b {
+				n++
+			}
+		}
+		return n, nil
+	}
+	times := func() ([]time.Time, error) {
+		var res []time.Time
+		for _, v := range nonEmpty {
+			t, err := formulaTime(v)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, t)
+		}
+		sort.Slice(res, func(i, j int) bool { return res[i].Before(res[j]) })
+		return res, nil
+	}
+
+	switch aggregation {
+	case "count", "count_all":
+		return float64(len(values)), nil
+	case "count_values", "not_empty":
+		return float64(len(nonEmpty)), nil
+	case "empty":
+		return float64(len(values) - len(nonEmpty)), nil
+	case "percent_not_empty":
+		return fraction(len(nonEmpty)), nil
+	case "percent_empty":
+		return fraction(len(values) - len(nonEmpty)), nil
+	case "unique":
+		unique := map[string]bool{}
+		for _, v := range nonEmpty {
+			unique[FormatFormulaValue(v)] = true
+		}
+		return float64(len(unique)), nil
+
+	case "sum", "average", "median", "min", "max", "range":
+		n, err := numbers()
+		if err != nil {
+			return nil, err
+		}
+		if aggregation == "sum" {
+			sum := 0.0
+			for _, f := range n {
+				sum += f
+			}
+			return sum, nil
+		}
+		if len(n) == 0 {
+			return nil, nil
+		}
+		switch aggregation {
+		case "average":
+			sum := 0.0
+			for _, f := range n {
+				sum += f
+			}
+			return sum / float64(len(n)), nil
+		case "median":
+			mid := len(n) / 2
+			if len(n)%2 == 1 {
+				return n[mid], nil
+			}
+			return (n[mid-1] + n[mid]) / 2, nil
+		case "min":
+			return n[0], nil
+		case "max":
+			return n[len(n)-1], nil
+		}
+		return n[len(n)-1] - n[0], nil
+
+	case "checked", "unchecked", "percent_checked", "percent_unchecked":
+		n, err := nChecked()
+		if err != nil {
+			return nil, err
+		}
+		switch aggregation {
+		case "checked":
+			return float64(n), nil
+		case "unchecked":
+			return float64(len(values) - n), nil
+		case "percent_checked":
+			return fraction(n), nil
+		}
+		return fraction(len(values) - n), nil
+
+	case "earliest_date", "latest_date", "date_range":
+		t, err := times()
+		if err != nil {
+			return nil, err
+		}
+		if len(t) == 0 {
+			return nil, nil
+		}
+		switch aggregation {
+		case "earliest_date":
+			return t[0], nil
+		case "latest_date":
+			return t[len(t)-1], nil
+		}
+		return math.Floor(t[len(t)-1].Sub(t[0]).Hours() / 24), nil
+	}
+	return nil, fmt.Errorf("unsupported rollup aggregation '%s'", aggregation)
+}
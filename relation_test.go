@@ -0,0 +1,205 @@
+package notionapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kjk/common/assert"
+)
+
+// newRelationTestRow returns a row related to 3 rows of collection
+// from newTestTableRow and a fake server that returns them
+func newRelationTestRow(t *testing.T, nRequests *int) (*TableRow, *Client) {
+	target := newTestTableRow().TableView.Collection
+	target.Schema["frml"].Formula = &ColumnFormula{Type: "function", Name: "multiply", Args: []FormulaArg{
+		fProp("nmbr"), fNum("2"),
+	}}
+	date := func(s string) []interface{} {
+		d := map[string]interface{}{"type": "date", "start_date": s}
+		return []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"d", d}}}}
+	}
+	pages := map[string]map[string]interface{}{
+		testBlockID(91): {
+			"title": spans("first", ""),
+			"nmbr":  spans("10", ""),
+			"AbCd":  spans("Yes", ""),
+			"slct":  spans("Done", ""),
+			"date":  date("2021-03-04"),
+		},
+		testBlockID(92): {
+			"title": spans("second", ""),
+			"nmbr":  spans("2.5", ""),
+			"slct":  spans("Done", ""),
+			"date":  date("2021-01-01"),
+		},
+		testBlockID(93): {
+			"title": spans("third", ""),
+			"slct":  spans("Todo", ""),
+		},
+	}
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			*nRequests++
+			var req syncRecordRequest
+			assert.NoError(t, jsonit.Unmarshal(body, &req))
+			blocks := map[string]interface{}{}
+			collections := map[string]interface{}{}
+			for _, r := range req.Requests {
+				id := r.Pointer.ID
+				if r.Pointer.Table == TableCollection && id == target.ID {
+					collections[id] = map[string]interface{}{
+						"role":  "reader",
+						"value": map[string]interface{}{"id": id, "schema": target.Schema},
+					}
+				}
+				if props, ok := pages[id]; ok && r.Pointer.Table == TableBlock {
+					blocks[id] = map[string]interface{}{
+						"role": "reader",
+						"value": map[string]interface{}{
+							"id": id, "type": "page", "properties": props,
+							"parent_id": target.ID, "parent_table": "collection",
+						},
+					}
+				}
+			}
+			rsp := map[string]interface{}{
+				"recordMap": map[string]interface{}{"block": blocks, "collection": collections},
+			}
+			return jsonit.Marshal(rsp)
+		},
+	}
+
+	rollup := func(name, target, aggregation string) *ColumnSchema {
+		return &ColumnSchema{
+			Name:             name,
+			Type:             ColumnTypeRollup,
+			RelationProperty: "rltn",
+			TargetProperty:   target,
+			Aggregation:      aggregation,
+		}
+	}
+	collection := &Collection{
+		ID: testBlockID(80),
+		Schema: map[string]*ColumnSchema{
+			"title": {Name: "Name", Type: ColumnTypeTitle},
+			"rltn":  {Name: "Tasks", Type: ColumnTypeRelation, CollectionID: ToNoDashID(target.ID)},
+			"r1":    rollup("Total", "nmbr", "sum"),
+			"r2":    rollup("Count", "title", "count"),
+			"r3":    rollup("Statuses", "slct", "unique"),
+			"r4":    rollup("Progress", "AbCd", "percent_checked"),
+			"r5":    rollup("Names", "Name", "show_original"),
+			"r6":    rollup("Last", "date", "latest_date"),
+			"r7":    rollup("Average", "Price", "average"),
+			"r8":    rollup("Doubled", "Formula", "show_original"),
+		},
+	}
+	tv := &TableView{Collection: collection}
+	row := &TableRow{
+		TableView: tv,
+		Page: &Block{ID: testBlockID(81), Properties: map[string]interface{}{
+			// we don't have access to the last one
+			"rltn": spans("‣", "p "+testBlockID(91), ",", "", "‣", "p "+testBlockID(92), ",", "", "‣", "p "+testBlockID(93), ",", "", "‣", "p "+testBlockID(94)),
+		}},
+	}
+	tv.Rows = []*TableRow{row}
+	return row, c
+}
+
+func TestResolveRelations(t *testing.T) {
+	nRequests := 0
+	row, c := newRelationTestRow(t, &nRequests)
+
+	assert.Nil(t, row.Related("Tasks"))
+	_, err := row.Rollup("Total")
+	assert.Error(t, err)
+
+	err = c.ResolveRelations(row.TableView)
+	assert.NoError(t, err)
+	// one for collections, one for pages
+	assert.Equal(t, 2, nRequests)
+
+	related := row.Related("Tasks")
+	assert.Equal(t, 3, len(related))
+	assert.Equal(t, testBlockID(91), related[0].ID)
+	assert.Nil(t, row.Related("Name"))
+
+	// already loaded pages are not fetched again
+	err = c.ResolveRelations(row.TableView)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, nRequests)
+
+	tests := []struct {
+		column string
+		exp    interface{}
+	}{
+		{"Total", 12.5},
+		{"Count", 3.0},
+		{"Statuses", 2.0},
+		{"Progress", 1.0 / 3},
+		{"Last", time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"Average", 6.25},
+	}
+	for _, tc := range tests {
+		v, err := row.Rollup(tc.column)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.exp, v)
+	}
+
+	v, err := row.Rollup("Names")
+	assert.NoError(t, err)
+	values := v.([]*CellValue)
+	assert.Equal(t, 3, len(values))
+	assert.Equal(t, "second", values[1].Text())
+
+	// formula targets are calculated for each related page
+	v, err = row.Rollup("Doubled")
+	assert.NoError(t, err)
+	values = v.([]*CellValue)
+	assert.Equal(t, 3, len(values))
+	assert.Equal(t, "20", values[0].String())
+	assert.Equal(t, "5", values[1].String())
+	assert.Equal(t, "0", values[2].String())
+
+	_, err = row.Rollup("Tasks")
+	assert.Error(t, err)
+
+	// rollups can be used in formulas
+	name := "concat"
+	formula := &ColumnFormula{Type: "function", Name: name, Args: []FormulaArg{
+		fProp("Names"), fStr(": "), fFunc("format", fProp("Total")),
+	}}
+	v, err = formula.Eval(row)
+	assert.NoError(t, err)
+	assert.Equal(t, "first, second, third: 12.5", v)
+}
+
+func TestAggregateRollup(t *testing.T) {
+	values := []interface{}{3.0, nil, 1.0, 2.0, 10.0}
+	tests := []struct {
+		aggregation string
+		exp         interface{}
+	}{
+		{"count_values", 4.0},
+		{"empty", 1.0},
+		{"percent_empty", 0.2},
+		{"median", 2.5},
+		{"min", 1.0},
+		{"max", 10.0},
+		{"range", 9.0},
+	}
+	for _, tc := range tests {
+		v, err := aggregateRollup(tc.aggregation, values)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.exp, v)
+	}
+
+	v, err := aggregateRollup("average", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	_, err = aggregateRollup("checked", values)
+	assert.Error(t, err)
+	_, err = aggregateRollup("bogus", values)
+	assert.Error(t, err)
+}
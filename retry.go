@@ -0,0 +1,149 @@
+package notionapi
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryInfo describes a failed request that is about to be retried
+type RetryInfo struct {
+	URI string
+	// Attempt is the number of the attempt that failed, starting with 1
+	Attempt int
+	// Delay is how long we'll wait before the next attempt
+	Delay time.Duration
+	// StatusCode is http status code or 0 if request failed
+	// without a response (e.g. network error)
+	StatusCode int
+	Err        error
+}
+
+// RetryPolicy controls how Client retries failed requests
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// 0 or 1 disables retries
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry. It doubles with each
+	// subsequent retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries (if > 0)
+	MaxDelay time.Duration
+	// Jitter randomizes delay by +/- Jitter fraction of it e.g. 0.2 means
+	// the delay is randomized between 80% and 120%
+	Jitter float64
+	// RetryStatusCodes is a list of http status codes to retry.
+	// If empty, we retry when APIError.Retryable() is true
+	RetryStatusCodes []int
+	// RetryNetworkErrors enables retrying of requests that failed
+	// without getting a response (connection reset, timeout etc.)
+	RetryNetworkErrors bool
+	// HonorRetryAfter makes us wait as long as Retry-After response
+	// header asks us to (capped by MaxDelay)
+	HonorRetryAfter bool
+	// OnRetry is called before each retry, e.g. to record metrics
+	OnRetry func(info *RetryInfo)
+}
+
+// DefaultRetryPolicy returns RetryPolicy used if Client.RetryPolicy is not set.
+// It retries rate-limited requests, 5xx errors and network errors
+// up to 3 times
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:        4,
+		BaseDelay:          time.Second * 3,
+		MaxDelay:           time.Second * 30,
+		Jitter:             0.2,
+		RetryNetworkErrors: true,
+		HonorRetryAfter:    true,
+	}
+}
+
+var defaultRetryPolicy = DefaultRetryPolicy()
+
+func (c *Client) getRetryPolicy() *RetryPolicy {
+	if c.RetryPolicy != nil {
+		return c.RetryPolicy
+	}
+	return defaultRetryPolicy
+}
+
+func (p *RetryPolicy) isRetryableStatusCode(apiErr *APIError) bool {
+	if len(p.RetryStatusCodes) == 0 {
+		return apiErr.Retryable()
+	}
+	for _, code := range p.RetryStatusCodes {
+		if code == apiErr.StatusCode {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RetryPolicy) shouldRetry(ctx context.Context, attempt int, err error) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	// ctx was cancelled or deadline exceeded, no point retrying
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if apiErr, ok := AsAPIError(err); ok {
+		return p.isRetryableStatusCode(apiErr)
+	}
+	return p.RetryNetworkErrors
+}
+
+// delay returns how long to wait before attempt+1
+func (p *RetryPolicy) delay(attempt int, err error) time.Duration {
+	if p.HonorRetryAfter {
+		if apiErr, ok := AsAPIError(err); ok && apiErr.RetryAfter > 0 {
+			d := apiErr.RetryAfter
+			if p.MaxDelay > 0 && d > p.MaxDelay {
+				d = p.MaxDelay
+			}
+			return d
+		}
+	}
+	d := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d > p.MaxDelay {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		// randomize between (1-Jitter) and (1+Jitter) of d
+		f := 1 - p.Jitter + rand.Float64()*2*p.Jitter
+		d = time.Duration(float64(d) * f)
+	}
+	return d
+}
+
+// parseRetryAfter parses value of Retry-After header, which is
+// either number of seconds or http date
+func parseRetryAfter(s string) time.Duration {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(s); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package notionapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kjk/common/assert"
+)
+
+func newFailingServer(nFailures int32, statusCode int) (*httptest.Server, *int32) {
+	var nRequests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&nRequests, 1)
+		if n <= nFailures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(statusCode)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	return ts, &nRequests
+}
+
+func TestRetryPolicyRetries(t *testing.T) {
+	ts, nRequests := newFailingServer(2, http.StatusServiceUnavailable)
+	defer ts.Close()
+
+	var retries []*RetryInfo
+	c := &Client{
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			OnRetry: func(info *RetryInfo) {
+				retries = append(retries, info)
+			},
+		},
+	}
+	d, err := c.doPostInternal(context.Background(), ts.URL, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(d))
+	assert.Equal(t, int32(3), atomic.LoadInt32(nRequests))
+	assert.Equal(t, 2, len(retries))
+	assert.Equal(t, 1, retries[0].Attempt)
+	assert.Equal(t, http.StatusServiceUnavailable, retries[0].StatusCode)
+}
+
+func TestRetryPolicyGivesUp(t *testing.T) {
+	ts, nRequests := newFailingServer(10, http.StatusTooManyRequests)
+	defer ts.Close()
+
+	c := &Client{
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts: 2,
+			BaseDelay:   time.Millisecond,
+		},
+	}
+	_, err := c.doPostInternal(context.Background(), ts.URL, nil)
+	assert.True(t, IsRateLimited(err))
+	assert.Equal(t, int32(2), atomic.LoadInt32(nRequests))
+}
+
+func TestRetryPolicyStatusCodes(t *testing.T) {
+	ts, nRequests := newFailingServer(10, http.StatusBadGateway)
+	defer ts.Close()
+
+	c := &Client{
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:      5,
+			BaseDelay:        time.Millisecond,
+			RetryStatusCodes: []int{http.StatusTooManyRequests},
+		},
+	}
+	_, err := c.doPostInternal(context.Background(), ts.URL, nil)
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(nRequests))
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	p := &RetryPolicy{
+		BaseDelay: time.Second,
+		MaxDelay:  time.Second * 5,
+	}
+	assert.Equal(t, time.Second, p.delay(1, nil))
+	assert.Equal(t, time.Second*2, p.delay(2, nil))
+	assert.Equal(t, time.Second*4, p.delay(3, nil))
+	assert.Equal(t, time.Second*5, p.delay(4, nil))
+
+	p.HonorRetryAfter = true
+	err := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Second * 3}
+	assert.Equal(t, time.Second*3, p.delay(1, err))
+	err.RetryAfter = time.Minute
+	assert.Equal(t, time.Second*5, p.delay(1, err))
+
+	p.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		d := p.delay(1, nil)
+		assert.True(t, d >= time.Millisecond*500 && d <= time.Millisecond*1500)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Second*7, parseRetryAfter("7"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("garbage"))
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	d := parseRetryAfter(future)
+	assert.True(t, d > time.Second*50 && d <= time.Minute)
+}
@@ -0,0 +1,261 @@
+package notionapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// colors of text and text background, used in AttrHighlight
+const (
+	ColorDefault          = "default"
+	ColorGray             = "gray"
+	ColorBrown            = "brown"
+	ColorOrange           = "orange"
+	ColorYellow           = "yellow"
+	ColorTeal             = "teal"
+	ColorBlue             = "blue"
+	ColorPurple           = "purple"
+	ColorPink             = "pink"
+	ColorRed              = "red"
+	ColorGrayBackground   = "gray_background"
+	ColorBrownBackground  = "brown_background"
+	ColorOrangeBackground = "orange_background"
+	ColorYellowBackground = "yellow_background"
+	ColorTealBackground   = "teal_background"
+	ColorBlueBackground   = "blue_background"
+	ColorPurpleBackground = "purple_background"
+	ColorPinkBackground   = "pink_background"
+	ColorRedBackground    = "red_background"
+)
+
+// NewAttrLink returns AttrLink attribute
+func NewAttrLink(uri string) TextAttr {
+	return TextAttr{AttrLink, uri}
+}
+
+// NewAttrHighlight returns AttrHighlight attribute. color is
+// one of Color* constants
+func NewAttrHighlight(color string) TextAttr {
+	return TextAttr{AttrHighlight, color}
+}
+
+// NewAttrUser returns AttrUser attribute (a mention of a user)
+func NewAttrUser(userID string) TextAttr {
+	return TextAttr{AttrUser, userID}
+}
+
+// NewAttrPage returns AttrPage attribute (a mention of a page)
+func NewAttrPage(pageID string) TextAttr {
+	return TextAttr{AttrPage, ToDashID(pageID)}
+}
+
+// validateDate returns an error if d is not a date Notion would accept
+func validateDate(d *Date) error {
+	if d == nil {
+		return errors.New("date is nil")
+	}
+	if _, err := time.Parse("2006-01-02", d.StartDate); err != nil {
+		return fmt.Errorf("invalid start date '%s', expected YYYY-MM-DD", d.StartDate)
+	}
+	if d.EndDate != "" {
+		if _, err := time.Parse("2006-01-02", d.EndDate); err != nil {
+			return fmt.Errorf("invalid end date '%s', expected YYYY-MM-DD", d.EndDate)
+		}
+	}
+	for _, tm := range []string{d.StartTime, d.EndTime} {
+		if tm == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", tm); err != nil {
+			return fmt.Errorf("invalid time '%s', expected HH:MM", tm)
+		}
+	}
+	if d.EndTime != "" && d.EndDate == "" {
+		return errors.New("date has end time but no end date")
+	}
+	switch d.Type {
+	case "date", "datetime", "daterange", "datetimerange":
+	default:
+		return fmt.Errorf("invalid date type '%s', expected \"date\", \"datetime\", \"daterange\" or \"datetimerange\"", d.Type)
+	}
+	hasTime := strings.HasPrefix(d.Type, "datetime")
+	if !hasTime && (d.StartTime != "" || d.EndTime != "") {
+		return fmt.Errorf("date of type '%s' can't have time", d.Type)
+	}
+	if hasTime && d.StartTime == "" {
+		return fmt.Errorf("date of type '%s' must have start time", d.Type)
+	}
+	if isRange := strings.HasSuffix(d.Type, "range"); isRange != (d.EndDate != "") {
+		return fmt.Errorf("only date of type \"daterange\" or \"datetimerange\" has end date")
+	}
+	return nil
+}
+
+// NewAttrDate returns AttrDate attribute. The date is serialized
+// the same way ParseTextSpans does it, so AttrGetDate works on it.
+// Returns an error if d is nil, has malformed date or time or Type that
+// doesn't match them. If DateFormat is not set, "relative" is used
+func NewAttrDate(d *Date) (TextAttr, error) {
+	if err := validateDate(d); err != nil {
+		return nil, err
+	}
+	if d.DateFormat == "" {
+		withFormat := *d
+		withFormat.DateFormat = "relative"
+		d = &withFormat
+	}
+	js, err := jsonit.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	// go through a map to get the same serialization (sorted keys,
+	// indentation) as when parsing
+	var m map[string]interface{}
+	if err = jsonit.Unmarshal(js, &m); err != nil {
+		return nil, err
+	}
+	js, err = jsonit.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return TextAttr{AttrDate, string(js)}, nil
+}
+
+// RichText is a builder for rich text i.e. a list of TextSpan.
+//
+//	rt := NewRichText().Text("Hello ").Bold("world").Link(" site", "https://notion.so")
+//	op, err := block.SetRichTitleOp(rt.Spans)
+//
+// Invalid values (e.g. a malformed date) are not added. The first such
+// error is returned by Err and Raw
+type RichText struct {
+	Spans []*TextSpan
+
+	err error
+}
+
+// NewRichText returns an empty RichText
+func NewRichText() *RichText {
+	return &RichText{}
+}
+
+// Text adds text with optional attributes e.g.
+// rt.Text("bold link", TextAttr{AttrBold}, NewAttrLink(uri))
+func (rt *RichText) Text(s string, attrs ...TextAttr) *RichText {
+	ts := &TextSpan{
+		Text:  s,
+		Attrs: attrs,
+	}
+	rt.Spans = append(rt.Spans, ts)
+	return rt
+}
+
+// Bold adds bold text
+func (rt *RichText) Bold(s string) *RichText {
+	return rt.Text(s, TextAttr{AttrBold})
+}
+
+// Italic adds italic text
+func (rt *RichText) Italic(s string) *RichText {
+	return rt.Text(s, TextAttr{AttrItalic})
+}
+
+// Strikethrough adds crossed-out text
+func (rt *RichText) Strikethrough(s string) *RichText {
+	return rt.Text(s, TextAttr{AttrStrikeThrought})
+}
+
+// Code adds inline code
+func (rt *RichText) Code(s string) *RichText {
+	return rt.Text(s, TextAttr{AttrCode})
+}
+
+// Link adds a link
+func (rt *RichText) Link(s string, uri string) *RichText {
+	return rt.Text(s, NewAttrLink(uri))
+}
+
+// Highlight adds colored text. color is one of Color* constants
+func (rt *RichText) Highlight(s string, color string) *RichText {
+	return rt.Text(s, NewAttrHighlight(color))
+}
+
+// User adds a mention of a user
+func (rt *RichText) User(userID string) *RichText {
+	return rt.Text(TextSpanSpecial, NewAttrUser(userID))
+}
+
+// Page adds a mention of a page
+func (rt *RichText) Page(pageID string) *RichText {
+	return rt.Text(TextSpanSpecial, NewAttrPage(pageID))
+}
+
+// Date adds a date. If d is not valid, nothing is added and
+// the error is returned by Err
+func (rt *RichText) Date(d *Date) *RichText {
+	attr, err := NewAttrDate(d)
+	if err != nil {
+		if rt.err == nil {
+			rt.err = err
+		}
+		return rt
+	}
+	return rt.Text(TextSpanSpecial, attr)
+}
+
+// Err returns the first error from building the rich text
+func (rt *RichText) Err() error {
+	return rt.err
+}
+
+// Raw returns rich text in the format used by Notion
+func (rt *RichText) Raw() ([]interface{}, error) {
+	if rt.err != nil {
+		return nil, rt.err
+	}
+	return TextSpansToRaw(rt.Spans)
+}
+
+// textAttrToRaw converts TextAttr to format used by Notion
+func textAttrToRaw(attr TextAttr) (interface{}, error) {
+	if AttrGetType(attr) == AttrDate {
+		if len(attr) != 2 {
+			return nil, fmt.Errorf("unexpected date attribute. Expected 2 values, got: %#v", attr)
+		}
+		var v map[string]interface{}
+		if err := jsonit.Unmarshal([]byte(attr[1]), &v); err != nil {
+			return nil, err
+		}
+		return []interface{}{AttrDate, v}, nil
+	}
+	res := make([]interface{}, len(attr))
+	for i, s := range attr {
+		res[i] = s
+	}
+	return res, nil
+}
+
+// TextSpansToRaw converts text spans to the format used by Notion in
+// block properties. It's the inverse of ParseTextSpans.
+// Returns an error if an attribute is malformed
+func TextSpansToRaw(spans []*TextSpan) ([]interface{}, error) {
+	res := make([]interface{}, 0, len(spans))
+	for _, ts := range spans {
+		if len(ts.Attrs) == 0 {
+			res = append(res, []interface{}{ts.Text})
+			continue
+		}
+		var attrs []interface{}
+		for _, attr := range ts.Attrs {
+			v, err := textAttrToRaw(attr)
+			if err != nil {
+				return nil, err
+			}
+			attrs = append(attrs, v)
+		}
+		res = append(res, []interface{}{ts.Text, attrs})
+	}
+	return res, nil
+}
@@ -0,0 +1,129 @@
+package notionapi
+
+import (
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+// jsonRoundTrip returns v as it would be seen by Notion server i.e.
+// after serializing to JSON and de-serializing
+func jsonRoundTrip(t *testing.T, v interface{}) interface{} {
+	d, err := jsonit.Marshal(v)
+	assert.NoError(t, err)
+	var res interface{}
+	err = jsonit.Unmarshal(d, &res)
+	assert.NoError(t, err)
+	return res
+}
+
+func TestTextSpansToRawRoundTrip(t *testing.T) {
+	fixtures := []string{title1, title2, title3, title4, title5, title6, title7, titleBig, titleWithComment}
+	for _, s := range fixtures {
+		var m map[string]interface{}
+		err := jsonit.Unmarshal([]byte(s), &m)
+		assert.NoError(t, err)
+		spans, err := ParseTextSpans(m["title"])
+		assert.NoError(t, err)
+
+		raw, err := TextSpansToRaw(spans)
+		assert.NoError(t, err)
+		raw = jsonRoundTrip(t, raw).([]interface{})
+		assert.Equal(t, m["title"], raw)
+
+		spans2, err := ParseTextSpans(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, spans, spans2)
+	}
+}
+
+func TestRichText(t *testing.T) {
+	tz := "America/Los_Angeles"
+	date := &Date{
+		DateFormat: "relative",
+		StartDate:  "2018-07-17",
+		StartTime:  "15:00",
+		TimeZone:   &tz,
+		Type:       "datetime",
+	}
+	rt := NewRichText().
+		Text("Text block with ").
+		Bold("bold ").
+		Text("link inside bold", TextAttr{AttrBold}, NewAttrLink("https://www.google.com")).
+		Italic("italic").
+		Strikethrough("strike").
+		Code("code").
+		Link("link", "http://blog.kowalczyk.info").
+		Highlight("colored", ColorTealBackground).
+		User("bb760e2d-d679-4b64-b2a9-03005b21870a").
+		Page("6682351e44bb4f9ca0e149b703265bdb").
+		Date(date)
+
+	assert.NoError(t, rt.Err())
+	raw, err := rt.Raw()
+	assert.NoError(t, err)
+	spans, err := ParseTextSpans(jsonRoundTrip(t, raw))
+	assert.NoError(t, err)
+	assert.Equal(t, rt.Spans, spans)
+
+	assert.Equal(t, 11, len(spans))
+	assert.Equal(t, "https://www.google.com", AttrGetLink(spans[2].Attrs[1]))
+	assert.Equal(t, ColorTealBackground, AttrGetHighlight(spans[7].Attrs[0]))
+	assert.Equal(t, "6682351e-44bb-4f9c-a0e1-49b703265bdb", AttrGetPageID(spans[9].Attrs[0]))
+	assert.Equal(t, date, AttrGetDate(spans[10].Attrs[0]))
+
+	// date serialized by us is the same as date from Notion
+	fromNotion := parseTextSpans(t, title5)
+	assert.Equal(t, fromNotion[0].Attrs[0], spans[10].Attrs[0])
+}
+
+func TestSetRichTitleOp(t *testing.T) {
+	b := &Block{ID: "6682351e-44bb-4f9c-a0e1-49b703265bdb"}
+	rt := NewRichText().Text("Text block with ").Bold("bold ")
+	op, err := b.SetRichTitleOp(rt.Spans)
+	assert.NoError(t, err)
+	assert.Equal(t, CommandSet, op.Command)
+	assert.Equal(t, []string{"properties", "title"}, op.Path)
+
+	var m map[string]interface{}
+	err = jsonit.Unmarshal([]byte(title3), &m)
+	assert.NoError(t, err)
+	assert.Equal(t, m["title"], jsonRoundTrip(t, op.Args))
+}
+
+func TestRichTextInvalidDate(t *testing.T) {
+	invalid := []*Date{
+		nil,
+		{},
+		{StartDate: "07/17/2018"},
+		{StartDate: "2018-07-17", StartTime: "3pm", Type: "datetime"},
+		{StartDate: "2018-07-17", EndDate: "tomorrow", Type: "daterange"},
+		{StartDate: "2018-07-17", EndTime: "15:00"},
+		// type is required and must match times and end date
+		{StartDate: "2018-07-17"},
+		{StartDate: "2018-07-17", Type: "bogus"},
+		{StartDate: "2018-07-17", StartTime: "15:00", Type: "date"},
+		{StartDate: "2018-07-17", Type: "datetime"},
+		{StartDate: "2018-07-17", Type: "daterange"},
+		{StartDate: "2018-07-17", EndDate: "2018-07-18", Type: "date"},
+	}
+	for _, d := range invalid {
+		_, err := NewAttrDate(d)
+		assert.Error(t, err)
+
+		rt := NewRichText().Text("due ").Date(d).Text(" end")
+		assert.Error(t, rt.Err())
+		assert.Equal(t, 2, len(rt.Spans))
+		_, err = rt.Raw()
+		assert.Error(t, err)
+	}
+
+	// malformed attribute is an error, not a panic
+	spans := []*TextSpan{{Text: TextSpanSpecial, Attrs: []TextAttr{{AttrDate, "not json"}}}}
+	_, err := TextSpansToRaw(spans)
+	assert.Error(t, err)
+	_, err = (&Block{ID: "6682351e-44bb-4f9c-a0e1-49b703265bdb"}).SetRichTitleOp(spans)
+	assert.Error(t, err)
+	spec := BlockSpec{Type: BlockText, Title: spans}
+	assert.Error(t, spec.validate())
+}
@@ -1,6 +1,9 @@
 package notionapi
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Command Types
 const (
@@ -23,14 +26,22 @@ type Operation struct {
 	Args    interface{} `json:"args"`
 }
 
+// SubmitTransaction executes a raw API call /api/v3/submitTransaction.
+// Operations are sent as is. Use Transaction and CommitTransaction to
+// validate them and update last_edited_time of modified blocks
 func (c *Client) SubmitTransaction(ops []*Operation) error {
+	return c.SubmitTransactionCtx(context.Background(), ops)
+}
+
+// SubmitTransactionCtx is like SubmitTransaction but can be cancelled with ctx
+func (c *Client) SubmitTransactionCtx(ctx context.Context, ops []*Operation) error {
 	req := &submitTransactionRequest{
 		Operations: ops,
 	}
 	// response is empty, as far as I can tell
 	var rsp map[string]interface{}
 	apiURL := "/api/v3/submitTransaction"
-	err := c.doNotionAPI(apiURL, req, &rsp, nil)
+	err := c.doNotionAPI(ctx, apiURL, req, &rsp, nil)
 	return err
 }
 
@@ -55,6 +66,23 @@ func (b *Block) SetTitleOp(title string) *Operation {
 	return b.buildOp(CommandSet, []string{"properties", "title"}, [][]string{{title}})
 }
 
+// SetRichTitleOp creates an Operation to set the title property
+// to a formatted text e.g. built with RichText
+func (b *Block) SetRichTitleOp(spans []*TextSpan) (*Operation, error) {
+	return b.SetPropertyOp("title", spans)
+}
+
+// SetPropertyOp creates an Operation to set a text property
+// (e.g. "title" or "caption") to a formatted text.
+// Returns an error if spans have malformed attributes
+func (b *Block) SetPropertyOp(name string, spans []*TextSpan) (*Operation, error) {
+	raw, err := TextSpansToRaw(spans)
+	if err != nil {
+		return nil, err
+	}
+	return b.buildOp(CommandSet, []string{"properties", name}, raw), nil
+}
+
 // TODO: Generalize this for the other fields
 // UpdatePropertiesOp creates an op to update the block's properties
 func (b *Block) UpdatePropertiesOp(source string) *Operation {
@@ -79,6 +107,23 @@ func (b *Block) UpdateOp(block *Block) *Operation {
 	return b.buildOp(CommandUpdate, []string{}, params)
 }
 
+// SetParentOp creates an operation to change the parent of the block.
+// It doesn't change content of the old and new parent
+func (b *Block) SetParentOp(parentID string, parentTable string) *Operation {
+	return b.buildOp(CommandUpdate, []string{}, map[string]interface{}{
+		"parent_id":    parentID,
+		"parent_table": parentTable,
+	})
+}
+
+// SetAliveOp creates an operation to soft-delete (alive is false)
+// or restore (alive is true) the block
+func (b *Block) SetAliveOp(alive bool) *Operation {
+	return b.buildOp(CommandUpdate, []string{}, map[string]interface{}{
+		"alive": alive,
+	})
+}
+
 // TODO: Make the input more strict
 // UpdateFormatOp creates an operation to update the block's format
 func (b *Block) UpdateFormatOp(params interface{}) *Operation {
@@ -111,6 +156,14 @@ func (b *Block) ListAfterFileIDsOp(fileID string) *Operation {
 	})
 }
 
+// ListAfterViewIDsOp creates an operation to add a collection view
+// to BlockCollectionView or BlockCollectionViewPage block
+func (b *Block) ListAfterViewIDsOp(viewID string) *Operation {
+	return b.buildOp(CommandListAfter, []string{"view_ids"}, map[string]string{
+		"id": viewID,
+	})
+}
+
 /*
 func buildLastEditedTimeOp(id string) *Operation {
 	args := map[string]interface{}{
@@ -0,0 +1,380 @@
+package notionapi
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TableExportOptions are options for TableView.WriteCSV,
+// TableView.WriteJSONLinesWithOptions and TableView.WriteColumnarJSON.
+// Columns are exported under their names. If several columns have
+// the same name, " (2)", " (3)" etc. is added to names of the next ones.
+// Rollups are empty unless relations are loaded with Client.ResolveRelations.
+// Export fails if a formula or a rollup can't be calculated
+type TableExportOptions struct {
+	// if true, columns of Collection.Schema hidden in the view are
+	// exported after visible columns, sorted by name
+	IncludeHidden bool
+	// names of users by id, for person columns. Users not in UserNames
+	// are looked up in TableView.Page (which is nil for views returned
+	// by Client.QueryCollectionWith). If that fails, user id is used
+	UserNames map[string]string
+}
+
+// exportColumn is a column exported by TableView.Write* functions
+type exportColumn struct {
+	id string
+	// unique name of the column
+	name   string
+	schema *ColumnSchema
+}
+
+// exportColumns returns columns to export: visible columns of the view
+// and, if opts.IncludeHidden, hidden columns of the collection
+func (tv *TableView) exportColumns(opts *TableExportOptions) []*exportColumn {
+	var res []*exportColumn
+	seen := map[string]bool{}
+	for _, col := range tv.Columns {
+		// view can refer to a column deleted from schema
+		if col.Schema == nil || seen[col.ID()] {
+			continue
+		}
+		seen[col.ID()] = true
+		res = append(res, &exportColumn{id: col.ID(), schema: col.Schema})
+	}
+	if opts.IncludeHidden && tv.Collection != nil {
+		var hidden []*exportColumn
+		for id, schema := range tv.Collection.Schema {
+			if !seen[id] {
+				hidden = append(hidden, &exportColumn{id: id, schema: schema})
+			}
+		}
+		sort.Slice(hidden, func(i, j int) bool {
+			if hidden[i].schema.Name == hidden[j].schema.Name {
+				return hidden[i].id < hidden[j].id
+			}
+			return hidden[i].schema.Name < hidden[j].schema.Name
+		})
+		res = append(res, hidden...)
+	}
+	// names are used as JSON keys, so they must be unique
+	used := map[string]bool{}
+	for _, col := range res {
+		name := col.schema.Name
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s (%d)", col.schema.Name, n)
+		}
+		used[name] = true
+		col.name = name
+	}
+	return res
+}
+
+// userName returns a name of a user with a given id
+func (tv *TableView) userName(userID string, opts *TableExportOptions) string {
+	if name, ok := opts.UserNames[userID]; ok {
+		return name
+	}
+	if tv.Page != nil {
+		return GetUserNameByID(tv.Page, userID)
+	}
+	return userID
+}
+
+// formatExportTime formats t as RFC 3339 date and time or, if hasTime
+// is false, as ISO-8601 date
+func formatExportTime(t time.Time, hasTime bool) string {
+	if !hasTime {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// formatExportDate formats d as ISO-8601 date (e.g. "2021-03-04"), date and
+// time (e.g. "2021-03-04T09:30:00-08:00") or interval (e.g.
+// "2021-03-04/2021-03-06") for date ranges
+func formatExportDate(d *Date) string {
+	format := func(date string, tm string) string {
+		t, err := dateToTime(&Date{StartDate: date, StartTime: tm, TimeZone: d.TimeZone})
+		if err != nil {
+			return strings.TrimSpace(date + " " + tm)
+		}
+		if tm == "" {
+			return t.Format("2006-01-02")
+		}
+		return t.Format(time.RFC3339)
+	}
+	s := format(d.StartDate, d.StartTime)
+	if d.EndDate != "" {
+		s += "/" + format(d.EndDate, d.EndTime)
+	}
+	return s
+}
+
+// exportValue returns a typed value of a cell of column colID: string,
+// float64, bool, []string or nil for empty values
+func (tv *TableView) exportValue(v *CellValue, colID string, opts *TableExportOptions) (interface{}, error) {
+	switch v.Schema.Type {
+	case ColumnTypeNumber:
+		if f, err := v.AsFloat(); err == nil {
+			return f, nil
+		}
+		return nil, nil
+	case ColumnTypeCheckbox:
+		return v.AsBool(), nil
+	case ColumnTypeCreatedTime, ColumnTypeLastEditedTime:
+		// always have time, even if it's midnight
+		ms := v.Row.Page.CreatedTime
+		if v.Schema.Type == ColumnTypeLastEditedTime {
+			ms = v.Row.Page.LastEditedTime
+		}
+		if ms == 0 {
+			return nil, nil
+		}
+		return formatExportTime(time.UnixMilli(ms).UTC(), true), nil
+	case ColumnTypeDate:
+		if d := v.AsDate(); d != nil {
+			return formatExportDate(d), nil
+		}
+		return nil, nil
+	case ColumnTypeMultiSelect:
+		return v.AsStrings(), nil
+	case ColumnTypePerson, ColumnTypeCreatedBy, ColumnTypeLastEditedBy:
+		var res []string
+		for _, id := range v.AsUserIDs() {
+			res = append(res, tv.userName(id, opts))
+		}
+		if v.Schema.Type != ColumnTypePerson && len(res) == 1 {
+			return res[0], nil
+		}
+		return res, nil
+	case ColumnTypeRelation:
+		var res []string
+		for _, id := range v.AsPageIDs() {
+			if b := tv.relatedPages[ToDashID(id)]; b != nil {
+				res = append(res, TextSpansToString(b.GetTitle()))
+			} else {
+				res = append(res, id)
+			}
+		}
+		return res, nil
+	case ColumnTypeFile:
+		return v.idsWithAttr(AttrLink), nil
+	case ColumnTypeFormula:
+		if v.Schema.Formula == nil {
+			break
+		}
+		res, err := v.Schema.Formula.Eval(v.Row)
+		if err != nil {
+			return nil, fmt.Errorf("column '%s' of row '%s': %w", v.Schema.Name, v.Row.Page.ID, err)
+		}
+		if t, ok := res.(time.Time); ok {
+			return formatExportTime(t, newFormulaEvaluator(v.Row).hasTime(v.Schema.Formula.root())), nil
+		}
+		return res, nil
+	case ColumnTypeRollup:
+		// without Client.ResolveRelations rollups are empty
+		if tv.relatedPages == nil {
+			break
+		}
+		res, err := v.Row.Rollup(colID)
+		if err != nil {
+			return nil, fmt.Errorf("column '%s' of row '%s': %w", v.Schema.Name, v.Row.Page.ID, err)
+		}
+		switch res := res.(type) {
+		case time.Time:
+			return formatExportTime(res, newFormulaEvaluator(v.Row).rollupHasTime(v.Schema)), nil
+		case []*CellValue:
+			_, targetID, _, err := v.Row.rollupRows(v.Schema)
+			if err != nil {
+				return nil, err
+			}
+			var values []string
+			for _, cv := range res {
+				value, err := tv.exportValue(cv, targetID, opts)
+				if err != nil {
+					return nil, err
+				}
+				if s := exportString(value); s != "" {
+					values = append(values, s)
+				}
+			}
+			return values, nil
+		}
+		return res, nil
+	}
+	if v.IsEmpty() {
+		return nil, nil
+	}
+	return v.Text(), nil
+}
+
+// exportString formats a value returned by exportValue for CSV
+func exportString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		// the way Notion exports checkboxes
+		if v {
+			return "Yes"
+		}
+		return "No"
+	case []string:
+		return strings.Join(v, ", ")
+	}
+	return FormatFormulaValue(v)
+}
+
+// exportRows calls fn with values of columns for each row
+func (tv *TableView) exportRows(columns []*exportColumn, opts *TableExportOptions, fn func([]interface{}) error) error {
+	for _, row := range tv.Rows {
+		if row.Page == nil {
+			continue
+		}
+		values := make([]interface{}, len(columns))
+		for i, col := range columns {
+			v, err := row.Get(col.id)
+			if err != nil {
+				return err
+			}
+			if values[i], err = tv.exportValue(v, col.id, opts); err != nil {
+				return err
+			}
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes rows of the table to w as CSV, with column names as
+// headers. Dates are formatted as ISO-8601, multi-select values are joined
+// with ", ", persons are resolved to names and relations to titles of pages
+// (if loaded with Client.ResolveRelations). opts can be nil
+func (tv *TableView) WriteCSV(w io.Writer, opts *TableExportOptions) error {
+	if opts == nil {
+		opts = &TableExportOptions{}
+	}
+	columns := tv.exportColumns(opts)
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	err := tv.exportRows(columns, opts, func(values []interface{}) error {
+		for i, v := range values {
+			record[i] = exportString(v)
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSONLines writes rows of the table to w as JSON Lines: one JSON
+// object per row, with column names as keys
+func (tv *TableView) WriteJSONLines(w io.Writer) error {
+	return tv.WriteJSONLinesWithOptions(w, nil)
+}
+
+// WriteJSONLinesWithOptions is like WriteJSONLines but with options.
+// Values are typed: numbers, booleans, arrays of strings for multi-select,
+// person, relation and file columns and ISO-8601 strings for dates.
+// Empty values are null. opts can be nil
+func (tv *TableView) WriteJSONLinesWithOptions(w io.Writer, opts *TableExportOptions) error {
+	if opts == nil {
+		opts = &TableExportOptions{}
+	}
+	columns := tv.exportColumns(opts)
+	keys := make([][]byte, len(columns))
+	for i, col := range columns {
+		d, err := jsonit.Marshal(col.name)
+		if err != nil {
+			return err
+		}
+		keys[i] = d
+	}
+	var buf bytes.Buffer
+	return tv.exportRows(columns, opts, func(values []interface{}) error {
+		// encode manually to preserve the order of columns
+		buf.Reset()
+		buf.WriteByte('{')
+		for i, v := range values {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(keys[i])
+			buf.WriteByte(':')
+			d, err := jsonit.Marshal(v)
+			if err != nil {
+				return err
+			}
+			buf.Write(d)
+		}
+		buf.WriteString("}\n")
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
+
+// ColumnarColumn is a column written by TableView.WriteColumnarJSON
+type ColumnarColumn struct {
+	Name string `json:"name"`
+	// ColumnTypeText, ColumnTypeNumber etc.
+	Type string `json:"type"`
+	// values for each row, typed as in TableView.WriteJSONLinesWithOptions
+	Values []interface{} `json:"values"`
+}
+
+// WriteColumnarJSON writes the table to w as a single JSON object
+// {"columns": [{"name": ..., "type": ..., "values": [...]}, ...]} where
+// values of a column are stored together, which is more compact than
+// JSON Lines and easy to load into data frames. opts can be nil
+func (tv *TableView) WriteColumnarJSON(w io.Writer, opts *TableExportOptions) error {
+	if opts == nil {
+		opts = &TableExportOptions{}
+	}
+	columns := tv.exportColumns(opts)
+	res := make([]*ColumnarColumn, len(columns))
+	for i, col := range columns {
+		res[i] = &ColumnarColumn{
+			Name:   col.name,
+			Type:   col.schema.Type,
+			Values: []interface{}{},
+		}
+	}
+	err := tv.exportRows(columns, opts, func(values []interface{}) error {
+		for i, v := range values {
+			res[i].Values = append(res[i].Values, v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	d, err := jsonit.Marshal(map[string]interface{}{"columns": res})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(d)
+	return err
+}
@@ -0,0 +1,180 @@
+package notionapi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+func newExportTestTable() *TableView {
+	row := newFormulaTestRow()
+	tv := row.TableView
+	row.Page.Properties["prsn"] = spans("‣", "u user-1", ",", "", "‣", "u user-2")
+	row.Page.Properties["rltn"] = spans("‣", "p "+testBlockID(7), ",", "", "‣", "p "+testBlockID(8))
+	row.Page.Properties["slct"] = spans("To, do", "")
+	tv.Collection.Schema["frml"].Formula = &ColumnFormula{
+		Type: "operator", Operator: "*", Args: []FormulaArg{fProp("nmbr"), fNum("2")},
+	}
+	tv.CollectionView = &CollectionView{
+		Format: &FormatTable{
+			TableProperties: []*TableProperty{
+				{Property: "nmbr", Visible: true},
+				{Property: "slct", Visible: true},
+				{Property: "tags", Visible: true},
+				{Property: "date", Visible: true},
+				{Property: "AbCd", Visible: true},
+				{Property: "prsn", Visible: true},
+				{Property: "rltn", Visible: true},
+				{Property: "frml", Visible: true},
+				{Property: "url_", Visible: false},
+				// deleted from schema
+				{Property: "gone", Visible: true},
+			},
+		},
+	}
+	tv.buildColumns()
+	tv.Rows = []*TableRow{row, {TableView: tv, Page: &Block{ID: testBlockID(52)}}}
+	tv.relatedPages = map[string]*Block{
+		testBlockID(7): {ID: testBlockID(7), Properties: titleProp("Other page", "")},
+	}
+	return tv
+}
+
+func TestTableViewWriteCSV(t *testing.T) {
+	tv := newExportTestTable()
+	var buf bytes.Buffer
+	opts := &TableExportOptions{UserNames: map[string]string{"user-1": "Jane"}}
+	assert.NoError(t, tv.WriteCSV(&buf, opts))
+	exp := `Name,Price,Status,Tags,Due,Done,Owner,Related,Formula
+Buy milk,12.5,"To, do","go, rust",2021-01-31T09:30:00Z,Yes,"Jane, user-2","Other page, ` + testBlockID(8) + `",25
+,,,,,No,,,0
+`
+	assert.Equal(t, exp, strings.ReplaceAll(buf.String(), "\r\n", "\n"))
+
+	buf.Reset()
+	assert.NoError(t, tv.WriteCSV(&buf, &TableExportOptions{IncludeHidden: true}))
+	header, _, _ := strings.Cut(buf.String(), "\n")
+	assert.Equal(t, "Name,Price,Status,Tags,Due,Done,Owner,Related,Formula,Link", header)
+}
+
+func TestTableViewWriteJSONLines(t *testing.T) {
+	tv := newExportTestTable()
+	var buf bytes.Buffer
+	assert.NoError(t, tv.WriteJSONLines(&buf))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Equal(t, 2, len(lines))
+	// keys are in the order of columns
+	assert.True(t, strings.HasPrefix(lines[0], `{"Name":"Buy milk","Price":12.5,"Status":"To, do","Tags":["go","rust"],`))
+	var rec map[string]interface{}
+	assert.NoError(t, jsonit.Unmarshal([]byte(lines[0]), &rec))
+	assert.Equal(t, "2021-01-31T09:30:00Z", rec["Due"])
+	assert.Equal(t, true, rec["Done"])
+	assert.Equal(t, []interface{}{"user-1", "user-2"}, rec["Owner"])
+	assert.Equal(t, 25.0, rec["Formula"])
+	assert.NoError(t, jsonit.Unmarshal([]byte(lines[1]), &rec))
+	assert.Nil(t, rec["Price"])
+	assert.Nil(t, rec["Tags"])
+	assert.Equal(t, false, rec["Done"])
+}
+
+func TestTableViewWriteColumnarJSON(t *testing.T) {
+	tv := newExportTestTable()
+	var buf bytes.Buffer
+	assert.NoError(t, tv.WriteColumnarJSON(&buf, &TableExportOptions{IncludeHidden: true}))
+	var res struct {
+		Columns []*ColumnarColumn `json:"columns"`
+	}
+	assert.NoError(t, jsonit.Unmarshal(buf.Bytes(), &res))
+	assert.Equal(t, 10, len(res.Columns))
+	price := res.Columns[1]
+	assert.Equal(t, "Price", price.Name)
+	assert.Equal(t, ColumnTypeNumber, price.Type)
+	assert.Equal(t, []interface{}{12.5, nil}, price.Values)
+	assert.Equal(t, "Link", res.Columns[9].Name)
+}
+
+func TestFormatExportDate(t *testing.T) {
+	tz := "America/Los_Angeles"
+	tests := []struct {
+		d   *Date
+		exp string
+	}{
+		{&Date{StartDate: "2021-03-04"}, "2021-03-04"},
+		{&Date{StartDate: "2021-03-04", EndDate: "2021-03-06"}, "2021-03-04/2021-03-06"},
+		{&Date{StartDate: "2021-03-04", StartTime: "09:30", TimeZone: &tz}, "2021-03-04T09:30:00-08:00"},
+		{&Date{StartDate: "bad"}, "bad"},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.exp, formatExportDate(tc.d))
+	}
+}
+
+func TestTableViewExportDuplicateNames(t *testing.T) {
+	tv := newExportTestTable()
+	tv.Collection.Schema["cost"] = &ColumnSchema{Name: "Price", Type: ColumnTypeNumber}
+	tv.Rows[0].Page.Properties["cost"] = spans("3", "")
+	props := tv.CollectionView.Format.TableProperties
+	tv.CollectionView.Format.TableProperties = append(props[:1], append([]*TableProperty{{Property: "cost", Visible: true}}, props[1:]...)...)
+	tv.buildColumns()
+
+	var buf bytes.Buffer
+	assert.NoError(t, tv.WriteJSONLines(&buf))
+	line, _, _ := strings.Cut(buf.String(), "\n")
+	assert.True(t, strings.HasPrefix(line, `{"Name":"Buy milk","Price":12.5,"Price (2)":3,`))
+}
+
+func TestTableViewExportFormulaDates(t *testing.T) {
+	tv := newExportTestTable()
+	row := tv.Rows[0]
+	setDate := func(d map[string]interface{}) {
+		row.Page.Properties["date"] = []interface{}{[]interface{}{"‣", []interface{}{[]interface{}{"d", d}}}}
+	}
+	tests := []struct {
+		d   map[string]interface{}
+		f   FormulaArg
+		exp string
+	}{
+		// midnight is a time, not a date without time
+		{map[string]interface{}{"type": "datetime", "start_date": "2021-01-31", "start_time": "00:00"}, fProp("date"), "2021-01-31T00:00:00Z"},
+		{map[string]interface{}{"type": "date", "start_date": "2021-01-31"}, fProp("date"), "2021-01-31"},
+		{map[string]interface{}{"type": "date", "start_date": "2021-01-31"}, fFunc("dateAdd", fProp("date"), fNum("1"), fStr("days")), "2021-02-01"},
+		{map[string]interface{}{"type": "date", "start_date": "2021-01-31"}, fFunc("dateAdd", fProp("date"), fNum("90"), fStr("minutes")), "2021-01-31T01:30:00Z"},
+	}
+	for _, tc := range tests {
+		setDate(tc.d)
+		tv.Collection.Schema["frml"].Formula = &ColumnFormula{Type: tc.f.Type, Name: tc.f.name(), Args: tc.f.Args, ID: tc.f.ID}
+		v, err := row.Get("frml")
+		assert.NoError(t, err)
+		value, err := tv.exportValue(v, "frml", &TableExportOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, tc.exp, value)
+	}
+}
+
+func TestTableViewExportRollups(t *testing.T) {
+	nRequests := 0
+	row, c := newRelationTestRow(t, &nRequests)
+	tv := row.TableView
+	// rollups are found by id, not by (here ambiguous) name
+	tv.Collection.Schema["r8"] = &ColumnSchema{
+		Name: "Total", Type: ColumnTypeRollup, RelationProperty: "rltn", TargetProperty: "nmbr", Aggregation: "max",
+	}
+	assert.NoError(t, c.ResolveRelations(tv))
+
+	var buf bytes.Buffer
+	assert.NoError(t, tv.WriteJSONLinesWithOptions(&buf, &TableExportOptions{IncludeHidden: true}))
+	var rec map[string]interface{}
+	assert.NoError(t, jsonit.Unmarshal(buf.Bytes(), &rec))
+	assert.Equal(t, 12.5, rec["Total"])
+	assert.Equal(t, 10.0, rec["Total (2)"])
+	assert.Equal(t, []interface{}{"first", "second", "third"}, rec["Names"])
+	assert.Equal(t, "2021-03-04", rec["Last"])
+
+	// errors are not exported as empty values
+	tv.Collection.Schema["r9"] = &ColumnSchema{
+		Name: "Broken", Type: ColumnTypeRollup, RelationProperty: "rltn", TargetProperty: "Missing", Aggregation: "sum",
+	}
+	assert.Error(t, tv.WriteJSONLinesWithOptions(&buf, &TableExportOptions{IncludeHidden: true}))
+}
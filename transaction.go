@@ -0,0 +1,315 @@
+package notionapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxTransactionSize is the default limit on the size (in bytes)
+// of JSON sent in a single submitTransaction request
+const DefaultMaxTransactionSize = 256 * 1024
+
+// tables that operations in Transaction can modify
+var transactionTables = map[string]bool{
+	TableBlock:          true,
+	TableCollection:     true,
+	TableCollectionView: true,
+}
+
+// Transaction accumulates operations to be sent with Client.CommitTransaction.
+//
+// When committed, it automatically adds operations that update last_edited_time
+// of modified blocks (and of PageID, if set), like Notion does.
+//
+// Operations passed in a single call to Add are a group: a record together
+// with e.g. adding it to its parent's content. If operations don't fit in
+// MaxSize bytes, they are split into multiple requests, but only between
+// groups. Updates of last_edited_time are sent with the group that
+// modified the block, so each request leaves records consistent.
+type Transaction struct {
+	// PageID is an optional id of a page that contains modified blocks.
+	// Its last_edited_time is updated together with modified blocks
+	PageID string
+	// MaxSize is a limit of the size of JSON sent in a single request.
+	// If 0, DefaultMaxTransactionSize is used
+	MaxSize int
+
+	ops    []*Operation
+	groups []*opGroup
+	now    int64
+	// ids of blocks created in this transaction
+	created map[string]bool
+	// ids of blocks whose last_edited_time must be updated
+	touchedMap map[string]bool
+}
+
+// opGroup is a group of operations that are always sent in the same request
+type opGroup struct {
+	ops []*Operation
+	// ids of blocks modified by ops, in order, whose last_edited_time is
+	// updated with this group
+	touched []string
+}
+
+// NewTransaction returns an empty Transaction
+func NewTransaction() *Transaction {
+	return &Transaction{
+		now:        Now(),
+		created:    map[string]bool{},
+		touchedMap: map[string]bool{},
+	}
+}
+
+// Add adds operations to the transaction. Operations passed in a single
+// call are never split between requests
+func (tx *Transaction) Add(ops ...*Operation) *Transaction {
+	if len(ops) == 0 {
+		return tx
+	}
+	tx.groups = append(tx.groups, &opGroup{ops: ops})
+	for _, op := range ops {
+		tx.ops = append(tx.ops, op)
+		// nil op is reported by Validate
+		if op == nil || op.Table != TableBlock {
+			continue
+		}
+		if op.Command == CommandSet && len(op.Path) == 0 {
+			// a new block is created with last_edited_time set,
+			// only its parent needs to be updated
+			tx.created[op.ID] = true
+			if args, ok := op.Args.(map[string]interface{}); ok {
+				parentID, _ := args["parent_id"].(string)
+				if parentID != "" && args["parent_table"] == TableBlock {
+					tx.Touch(parentID)
+				}
+			}
+			continue
+		}
+		tx.Touch(op.ID)
+	}
+	return tx
+}
+
+// Touch marks block as modified so that its last_edited_time is updated.
+// The update is sent with the most recently added group of operations
+func (tx *Transaction) Touch(blockID string) {
+	if tx.touchedMap[blockID] {
+		return
+	}
+	tx.touchedMap[blockID] = true
+	if len(tx.groups) == 0 {
+		tx.groups = append(tx.groups, &opGroup{})
+	}
+	g := tx.groups[len(tx.groups)-1]
+	g.touched = append(g.touched, blockID)
+}
+
+// groupOperations returns operations of each group, including automatically
+// added updates of last_edited_time. Update of PageID is in the first group
+func (tx *Transaction) groupOperations() [][]*Operation {
+	var res [][]*Operation
+	for i, g := range tx.groups {
+		ops := append([]*Operation{}, g.ops...)
+		touched := g.touched
+		if i == 0 && tx.PageID != "" && len(tx.ops) > 0 && !tx.touchedMap[tx.PageID] {
+			touched = append(touched, tx.PageID)
+		}
+		for _, id := range touched {
+			if tx.created[id] {
+				continue
+			}
+			b := &Block{ID: id}
+			ops = append(ops, b.UpdateOp(&Block{LastEditedTime: tx.now}))
+		}
+		res = append(res, ops)
+	}
+	return res
+}
+
+// Operations returns all operations in the transaction, including
+// automatically added updates of last_edited_time
+func (tx *Transaction) Operations() []*Operation {
+	var res []*Operation
+	for _, ops := range tx.groupOperations() {
+		res = append(res, ops...)
+	}
+	return res
+}
+
+func validateOperation(op *Operation) error {
+	if op == nil {
+		return errors.New("operation is nil")
+	}
+	if !transactionTables[op.Table] {
+		return fmt.Errorf("operation on '%s' has invalid table '%s'", op.ID, op.Table)
+	}
+	if !IsValidDashID(op.ID) {
+		return fmt.Errorf("operation has invalid id '%s'", op.ID)
+	}
+	if op.Path == nil {
+		return fmt.Errorf("operation on '%s' has nil path", op.ID)
+	}
+	for _, s := range op.Path {
+		if s == "" {
+			return fmt.Errorf("operation on '%s' has empty element in path %v", op.ID, op.Path)
+		}
+	}
+	switch op.Command {
+	case CommandSet:
+		// args can be anything, including nil
+	case CommandUpdate:
+		if op.Args == nil {
+			return fmt.Errorf("update operation on '%s' has no args", op.ID)
+		}
+	case CommandListAfter, CommandListRemove:
+		if len(op.Path) == 0 {
+			return fmt.Errorf("%s operation on '%s' must have a path", op.Command, op.ID)
+		}
+		var id interface{}
+		switch args := op.Args.(type) {
+		case map[string]string:
+			id = args["id"]
+		case map[string]interface{}:
+			id = args["id"]
+		}
+		if s, _ := id.(string); s == "" {
+			return fmt.Errorf("%s operation on '%s' must have 'id' in args", op.Command, op.ID)
+		}
+	default:
+		return fmt.Errorf("operation on '%s' has invalid command '%s'", op.ID, op.Command)
+	}
+	return nil
+}
+
+// Validate returns an error if any of the operations is invalid
+func (tx *Transaction) Validate() error {
+	if len(tx.ops) == 0 {
+		return errors.New("transaction has no operations")
+	}
+	for i, op := range tx.ops {
+		if err := validateOperation(op); err != nil {
+			return fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	for id := range tx.touchedMap {
+		if !IsValidDashID(id) {
+			return fmt.Errorf("invalid id of modified block '%s'", id)
+		}
+	}
+	if tx.PageID != "" && !IsValidDashID(tx.PageID) {
+		return fmt.Errorf("invalid PageID '%s'", tx.PageID)
+	}
+	return nil
+}
+
+// requests validates operations and splits them into requests
+// that are not bigger than MaxSize. Groups of operations are not split
+func (tx *Transaction) requests() ([]*submitTransactionRequest, error) {
+	if err := tx.Validate(); err != nil {
+		return nil, err
+	}
+	maxSize := tx.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxTransactionSize
+	}
+	var res []*submitTransactionRequest
+	curr := &submitTransactionRequest{}
+	// approximate size of {"operations": []}
+	currSize := 32
+	for _, ops := range tx.groupOperations() {
+		size := 0
+		for _, op := range ops {
+			d, err := jsonit.MarshalIndent(op, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			// in the request each line is indented by 4 more spaces
+			size += len(d) + 4*bytes.Count(d, []byte{'\n'}) + 6
+		}
+		if size+32 > maxSize {
+			return nil, fmt.Errorf("operations on '%s' are too big (%d bytes)", ops[0].ID, size)
+		}
+		if currSize+size > maxSize {
+			res = append(res, curr)
+			curr = &submitTransactionRequest{}
+			currSize = 32
+		}
+		curr.Operations = append(curr.Operations, ops...)
+		currSize += size
+	}
+	res = append(res, curr)
+	return res, nil
+}
+
+// DryRun returns JSON bodies of requests that CommitTransaction would send
+func (tx *Transaction) DryRun() ([]string, error) {
+	reqs, err := tx.requests()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, req := range reqs {
+		d, err := jsonit.MarshalIndent(req, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, string(d))
+	}
+	return res, nil
+}
+
+// CommitError is returned by CommitTransaction when a transaction was
+// split into multiple requests and one of them failed
+type CommitError struct {
+	// Committed is the number of requests that were submitted
+	// successfully. Their operations are saved by Notion
+	Committed int
+	// Total is the number of requests the transaction was split into
+	Total int
+	// Operations are operations of the committed requests
+	Operations []*Operation
+	// Err is the error of the failed request
+	Err error
+}
+
+// Error returns error string
+func (e *CommitError) Error() string {
+	return fmt.Sprintf("request %d of %d of transaction failed: %s", e.Committed+1, e.Total, e.Err)
+}
+
+// Unwrap returns the error of the failed request
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// CommitTransaction validates and sends operations in the transaction
+// with one or more /api/v3/submitTransaction requests.
+//
+// A transaction split into multiple requests is not atomic: requests are
+// sent one after another and if one of them fails, the error is *CommitError
+// and operations from requests before it are already saved.
+// Use DryRun to check how many requests will be sent
+func (c *Client) CommitTransaction(tx *Transaction) error {
+	return c.CommitTransactionCtx(context.Background(), tx)
+}
+
+// CommitTransactionCtx is like CommitTransaction but can be cancelled with ctx
+func (c *Client) CommitTransactionCtx(ctx context.Context, tx *Transaction) error {
+	reqs, err := tx.requests()
+	if err != nil {
+		return err
+	}
+	var committed []*Operation
+	for i, req := range reqs {
+		if err = c.SubmitTransactionCtx(ctx, req.Operations); err != nil {
+			if len(reqs) == 1 {
+				return err
+			}
+			return &CommitError{Committed: i, Total: len(reqs), Operations: committed, Err: err}
+		}
+		committed = append(committed, req.Operations...)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package notionapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kjk/common/assert"
+)
+
+func TestTransactionValidate(t *testing.T) {
+	b := &Block{ID: testBlockID(1)}
+	tests := []*Operation{
+		{ID: testBlockID(1), Table: "space_view", Path: []string{}, Command: CommandSet},
+		{ID: "not-an-id", Table: TableBlock, Path: []string{}, Command: CommandSet},
+		{ID: testBlockID(1), Table: TableBlock, Path: nil, Command: CommandSet},
+		{ID: testBlockID(1), Table: TableBlock, Path: []string{"properties", ""}, Command: CommandSet},
+		{ID: testBlockID(1), Table: TableBlock, Path: []string{}, Command: "delete"},
+		{ID: testBlockID(1), Table: TableBlock, Path: []string{}, Command: CommandUpdate},
+		{ID: testBlockID(1), Table: TableBlock, Path: []string{}, Command: CommandListAfter, Args: map[string]string{"id": testBlockID(2)}},
+		{ID: testBlockID(1), Table: TableBlock, Path: []string{"content"}, Command: CommandListRemove, Args: map[string]string{}},
+		nil,
+	}
+	for _, op := range tests {
+		tx := NewTransaction().Add(op)
+		assert.Error(t, tx.Validate())
+		_, err := tx.DryRun()
+		assert.Error(t, err)
+	}
+
+	assert.Error(t, NewTransaction().Validate())
+
+	tx := NewTransaction().Add(
+		b.SetTitleOp("title"),
+		b.UpdateFormatOp(map[string]interface{}{"page_small_text": true}),
+		b.ListAfterContentOp(testBlockID(2), ""),
+		&Operation{ID: testBlockID(3), Table: TableCollection, Path: []string{"name"}, Command: CommandSet, Args: [][]string{{"name"}}},
+	)
+	assert.NoError(t, tx.Validate())
+}
+
+func TestTransactionLastEditedTime(t *testing.T) {
+	tx := NewTransaction()
+	tx.now = 1551762900000
+	tx.PageID = testBlockID(1)
+	b2 := &Block{ID: testBlockID(2)}
+	b3 := &Block{ID: testBlockID(3)}
+	tx.Add(b2.SetTitleOp("a"), b3.SetTitleOp("b"), b2.SetTitleOp("c"))
+	// updates of collections don't update any blocks
+	tx.Add(&Operation{ID: testBlockID(4), Table: TableCollection, Path: []string{"name"}, Command: CommandSet})
+
+	// updates are sent with the group that modified the blocks,
+	// the update of the page with the first group
+	ops := tx.Operations()
+	assert.Equal(t, 7, len(ops))
+	assert.Equal(t, testBlockID(4), ops[6].ID)
+	exp := map[string]interface{}{"last_edited_time": tx.now}
+	for i, id := range []string{testBlockID(2), testBlockID(3), testBlockID(1)} {
+		op := ops[3+i]
+		assert.Equal(t, id, op.ID)
+		assert.Equal(t, CommandUpdate, op.Command)
+		assert.Equal(t, []string{}, op.Path)
+		assert.Equal(t, exp, op.Args)
+	}
+}
+
+func TestTransactionSplit(t *testing.T) {
+	tx := NewTransaction()
+	tx.MaxSize = 2048
+	tx.PageID = testBlockID(1)
+	for i := 0; i < 40; i++ {
+		b := &Block{ID: testBlockID(100 + i)}
+		tx.Add(b.SetTitleOp(strings.Repeat("x", 100)))
+	}
+	// 40 title ops + 41 last edited time ops
+	nOps := len(tx.Operations())
+	assert.Equal(t, 81, nOps)
+
+	bodies, err := tx.DryRun()
+	assert.NoError(t, err)
+	assert.True(t, len(bodies) > 1)
+	n := 0
+	for _, body := range bodies {
+		assert.True(t, len(body) <= tx.MaxSize)
+		var req submitTransactionRequest
+		err = json.Unmarshal([]byte(body), &req)
+		assert.NoError(t, err)
+		n += len(req.Operations)
+	}
+	assert.Equal(t, nOps, n)
+
+	// a single operation that doesn't fit is an error
+	b := &Block{ID: testBlockID(1)}
+	tx = NewTransaction().Add(b.SetTitleOp(strings.Repeat("x", 4096)))
+	tx.MaxSize = 2048
+	_, err = tx.DryRun()
+	assert.Error(t, err)
+}
+
+func TestTransactionSplitGroups(t *testing.T) {
+	tx := NewTransaction()
+	tx.MaxSize = 2048
+	for i := 0; i < 20; i++ {
+		b := &Block{ID: testBlockID(100 + i)}
+		parent := &Block{ID: testBlockID(200 + i)}
+		tx.Add(b.SetTitleOp(strings.Repeat("x", 100)), parent.ListAfterContentOp(b.ID, ""))
+	}
+	reqs, err := tx.requests()
+	assert.NoError(t, err)
+	assert.True(t, len(reqs) > 1)
+	for _, req := range reqs {
+		// set, listAfter and 2 last edited time updates are never split
+		assert.Equal(t, 0, len(req.Operations)%4)
+		ops := req.Operations
+		for i := 0; i < len(ops); i += 4 {
+			assert.Equal(t, ops[i].ID, ops[i+2].ID)
+			assert.Equal(t, ops[i+1].ID, ops[i+3].ID)
+		}
+	}
+
+	// a group that doesn't fit is an error
+	b := &Block{ID: testBlockID(1)}
+	tx = NewTransaction()
+	tx.MaxSize = 2048
+	ops := []*Operation{}
+	for i := 0; i < 20; i++ {
+		ops = append(ops, b.SetTitleOp(strings.Repeat("x", 100)))
+	}
+	tx.Add(ops...)
+	_, err = tx.DryRun()
+	assert.Error(t, err)
+}
+
+func TestCommitTransactionPartial(t *testing.T) {
+	errFailed := errors.New("failed")
+	nSent := 0
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			nSent++
+			if nSent == 2 {
+				return nil, errFailed
+			}
+			return []byte("{}"), nil
+		},
+	}
+	tx := NewTransaction()
+	tx.MaxSize = 1024
+	for i := 0; i < 10; i++ {
+		b := &Block{ID: testBlockID(100 + i)}
+		tx.Add(b.SetTitleOp(strings.Repeat("x", 100)))
+	}
+	bodies, err := tx.DryRun()
+	assert.NoError(t, err)
+	err = c.CommitTransaction(tx)
+	var commitErr *CommitError
+	assert.True(t, errors.As(err, &commitErr))
+	assert.Equal(t, 1, commitErr.Committed)
+	assert.Equal(t, len(bodies), commitErr.Total)
+	assert.True(t, len(commitErr.Operations) > 0)
+	assert.Equal(t, testBlockID(100), commitErr.Operations[0].ID)
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 2, nSent)
+}
+
+func TestCommitTransaction(t *testing.T) {
+	var sent []string
+	c := &Client{
+		httpPostOverride: func(ctx context.Context, uri string, body []byte, headers ...http.Header) ([]byte, error) {
+			assert.True(t, strings.Contains(uri, "/api/v3/submitTransaction"))
+			sent = append(sent, string(body))
+			return []byte("{}"), nil
+		},
+	}
+	tx := NewTransaction()
+	tx.MaxSize = 1024
+	for i := 0; i < 10; i++ {
+		b := &Block{ID: testBlockID(100 + i)}
+		tx.Add(b.SetTitleOp(strings.Repeat("x", 100)))
+	}
+	bodies, err := tx.DryRun()
+	assert.NoError(t, err)
+	err = c.CommitTransaction(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, bodies, sent)
+}
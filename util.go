@@ -1,6 +1,7 @@
 package notionapi
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -167,10 +168,10 @@ func (e *ErrPageNotFound) Error() string {
 	return fmt.Sprintf("couldn't retrieve page '%s'", pageID)
 }
 
-// IsErrPageNotFound returns true if err is an instance of ErrPageNotFound
+// IsErrPageNotFound returns true if err is (or wraps) an instance of ErrPageNotFound
 func IsErrPageNotFound(err error) bool {
-	_, ok := err.(*ErrPageNotFound)
-	return ok
+	var e *ErrPageNotFound
+	return errors.As(err, &e)
 }
 
 func closeNoError(c io.Closer) {